@@ -0,0 +1,452 @@
+// client package contains a json-rpc client for soroban nodes
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"soroban/internal/common"
+	"soroban/services"
+
+	"golang.org/x/net/proxy"
+)
+
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultRetries    = 2
+	DefaultRetryDelay = 500 * time.Millisecond
+
+	MaxResponseSize = 4 << 20
+
+	rpcPath = "/rpc"
+)
+
+// Json-rpc error codes of soroban nodes
+const (
+	CodeParseError        = common.CodeParseError
+	CodeInvalidRequest    = common.CodeInvalidRequest
+	CodeMethodNotFound    = common.CodeMethodNotFound
+	CodeInvalidArgs       = common.CodeInvalidArgs
+	CodeInternal          = common.CodeInternal
+	CodeInvalidSignature  = common.CodeInvalidSignature
+	CodeTimestampOutRange = common.CodeTimestampOutRange
+	CodeForbidden         = common.CodeForbidden
+	CodeQuotaExceeded     = common.CodeQuotaExceeded
+	CodeRateLimited       = common.CodeRateLimited
+	CodeInvalidStamp      = common.CodeInvalidStamp
+	CodeReplayed          = common.CodeReplayed
+)
+
+// Error is a json-rpc error returned by soroban nodes
+type Error = common.Error
+
+var (
+	ErrNoNodes = errors.New("no soroban nodes")
+	// ErrMaybeApplied wrap errors of write calls which may have been applied by a node
+	ErrMaybeApplied = errors.New("call may have been applied")
+
+	// readMethods are retried on the next node after any node error.
+	// Other methods are retried only if they were not processed by the node.
+	readMethods = map[string]bool{
+		"directory.List": true,
+		"directory.Wait": true,
+	}
+)
+
+// DialContextFunc dial network connections, ie through a SOCKS proxy
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Client call soroban nodes json-rpc api.
+// Failed calls are retried on the next node, write calls only if they were not sent or rate limited.
+type Client struct {
+	mtx   sync.Mutex
+	nodes []string
+	next  int
+
+	httpClient *http.Client
+	dial       DialContextFunc
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+
+	id atomic.Uint64
+}
+
+type Option func(*Client)
+
+// WithDialer use dial for node connections
+func WithDialer(dial DialContextFunc) Option {
+	return func(c *Client) {
+		c.dial = dial
+	}
+}
+
+// WithSOCKS5 connect to nodes through a SOCKS5 proxy (ie Tor 127.0.0.1:9050).
+// Host names are resolved by the proxy.
+func WithSOCKS5(address string) Option {
+	return func(c *Client) {
+		dialer, err := proxy.SOCKS5("tcp", address, nil, proxy.Direct)
+		if err != nil {
+			c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return nil, err
+			}
+			return
+		}
+		if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+			c.dial = contextDialer.DialContext
+			return
+		}
+		c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.Dial(network, addr)
+		}
+	}
+}
+
+// WithTimeout set calls timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries set the count of retries on other nodes
+func WithRetries(retries int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryDelay = delay
+	}
+}
+
+// New return a client for nodes urls (ie http://address.onion)
+func New(nodes []string, options ...Option) (*Client, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	c := &Client{
+		timeout:    DefaultTimeout,
+		retries:    DefaultRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+	c.SetNodes(nodes)
+	for _, option := range options {
+		option(c)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.dial != nil {
+		transport.Proxy = nil
+		transport.DialContext = c.dial
+	}
+	c.httpClient = &http.Client{
+		Transport: transport,
+		Timeout:   c.timeout,
+	}
+	return c, nil
+}
+
+// Nodes return nodes urls
+func (c *Client) Nodes() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]string{}, c.nodes...)
+}
+
+// SetNodes replace nodes urls
+func (c *Client) SetNodes(nodes []string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.nodes = nil
+	for _, node := range nodes {
+		c.nodes = append(c.nodes, strings.TrimSuffix(node, "/"))
+	}
+	c.next = 0
+}
+
+// Node return the node of next calls
+func (c *Client) Node() string {
+	return c.node(false)
+}
+
+// node return current node, or next node if failed is set
+func (c *Client) node(failed bool) string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if failed {
+		c.next = (c.next + 1) % len(c.nodes)
+	}
+	return c.nodes[c.next]
+}
+
+// Call method with args, result is decoded from json-rpc result.
+// Json-rpc errors are returned as *Error, with Code* codes.
+// Write calls errors are wrapped with ErrMaybeApplied if the node may have applied the call,
+// ie on lost responses or replayed signatures.
+func (c *Client) Call(ctx context.Context, method string, args, result interface{}) error {
+	request, err := c.request(method, args)
+	if err != nil {
+		return err
+	}
+
+	write := !readMethods[method]
+	node := c.node(false)
+	for attempt := 0; ; attempt++ {
+		err = c.call(ctx, node, request, result)
+		if err == nil {
+			return nil
+		}
+		if !retryable(err, write) || attempt >= c.retries {
+			if write && maybeApplied(err) {
+				return fmt.Errorf("%w: %w", ErrMaybeApplied, err)
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.retryDelay):
+		}
+		node = c.node(true)
+	}
+}
+
+// callNode call method on the current node only, next calls are sent to the next node on node errors.
+func (c *Client) callNode(ctx context.Context, method string, args, result interface{}) error {
+	request, err := c.request(method, args)
+	if err != nil {
+		return err
+	}
+	err = c.call(ctx, c.node(false), request, result)
+	if err != nil && retryable(err, false) {
+		c.node(true)
+	}
+	return err
+}
+
+func (c *Client) request(method string, args interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.id.Add(1),
+		"method":  method,
+		"params":  []interface{}{args},
+	})
+}
+
+func (c *Client) call(ctx context.Context, node string, request []byte, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, node+rpcPath, bytes.NewReader(request))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &nodeError{node: node, sent: !dialFailed(err), err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+	if err != nil {
+		return &nodeError{node: node, sent: true, err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &nodeError{node: node, status: resp.StatusCode, sent: true, err: fmt.Errorf("http status %d", resp.StatusCode)}
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return &nodeError{node: node, sent: true, err: err}
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// Get decode json response of path (ie /stats or /status), failed requests are retried on the next node.
+func (c *Client) Get(ctx context.Context, path string, result interface{}) error {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	node := c.node(false)
+	for attempt := 0; ; attempt++ {
+		err := c.get(ctx, node+path, result)
+		if err == nil || !retryable(err, false) || attempt >= c.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.retryDelay):
+		}
+		node = c.node(true)
+	}
+}
+
+func (c *Client) get(ctx context.Context, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &nodeError{node: url, sent: !dialFailed(err), err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+	if err != nil {
+		return &nodeError{node: url, sent: true, err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &nodeError{node: url, status: resp.StatusCode, sent: true, err: fmt.Errorf("http status %d", resp.StatusCode)}
+	}
+	return json.Unmarshal(body, result)
+}
+
+// nodeError is a transport error, call is retried on next node.
+// Sent is false when the request did not reach the node.
+type nodeError struct {
+	node   string
+	status int
+	sent   bool
+	err    error
+}
+
+func (e *nodeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.node, e.err)
+}
+
+func (e *nodeError) Unwrap() error {
+	return e.err
+}
+
+// retryable return true for node errors and rate limited calls.
+// Write calls are retried only if the node did not process them, a retry on another node could apply them twice.
+func retryable(err error, write bool) bool {
+	var nodeErr *nodeError
+	if errors.As(err, &nodeErr) {
+		if !nodeErr.sent || nodeErr.status == http.StatusTooManyRequests {
+			return true
+		}
+		return !write && (nodeErr.status == 0 || nodeErr.status >= http.StatusInternalServerError)
+	}
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == CodeRateLimited
+	}
+	return false
+}
+
+// maybeApplied return true if a node may have applied the call:
+// response is lost, or the signature was already used (ie by a previous call propagated to this node).
+func maybeApplied(err error) bool {
+	var nodeErr *nodeError
+	if errors.As(err, &nodeErr) {
+		return nodeErr.sent && (nodeErr.status == 0 || nodeErr.status >= http.StatusInternalServerError)
+	}
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == CodeReplayed
+	}
+	return false
+}
+
+// dialFailed return true if connection to node (or SOCKS proxy) failed, request was not sent
+func dialFailed(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial" || strings.HasPrefix(opErr.Op, "socks")
+	}
+	return false
+}
+
+// List return key entries, use SignEntries for confidential keys.
+func (c *Client) List(ctx context.Context, args services.DirectoryEntries) (services.DirectoryEntriesResponse, error) {
+	var result services.DirectoryEntriesResponse
+	err := c.Call(ctx, "directory.List", &args, &result)
+	return result, err
+}
+
+// Add entry to key, use SignEntry for readonly keys.
+func (c *Client) Add(ctx context.Context, args services.DirectoryEntry) error {
+	var result services.Response
+	return c.Call(ctx, "directory.Add", &args, &result)
+}
+
+// Remove entry from key, use SignEntry for readonly keys.
+func (c *Client) Remove(ctx context.Context, args services.DirectoryEntry) error {
+	var result services.Response
+	return c.Call(ctx, "directory.Remove", &args, &result)
+}
+
+// Wait return entries added to key after cursor or known entries, empty on timeout.
+// Cursors are positions on the node which returned them, waits with cursor are not retried on other nodes:
+// on node errors, next calls are sent to the next node (see Node) and the cursor must be replaced by known entries.
+func (c *Client) Wait(ctx context.Context, args services.DirectoryWait) (services.DirectoryEntriesResponse, error) {
+	var result services.DirectoryEntriesResponse
+	var err error
+	if len(args.Cursor) > 0 {
+		err = c.callNode(ctx, "directory.Wait", &args, &result)
+	} else {
+		err = c.Call(ctx, "directory.Wait", &args, &result)
+	}
+	return result, err
+}
+
+// Announces return nodes announced on announceKey
+func (c *Client) Announces(ctx context.Context, announceKey string) ([]services.AnnounceInfo, error) {
+	response, err := c.List(ctx, services.DirectoryEntries{Name: announceKey})
+	if err != nil {
+		return nil, err
+	}
+	var result []services.AnnounceInfo
+	for _, entry := range response.Entries {
+		var info services.AnnounceInfo
+		if json.Unmarshal([]byte(entry), &info) != nil || len(info.Url) == 0 {
+			continue
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// UpdateNodes replace nodes with nodes announced on announceKey, nodes are kept if none is announced.
+func (c *Client) UpdateNodes(ctx context.Context, announceKey string) error {
+	announces, err := c.Announces(ctx, announceKey)
+	if err != nil {
+		return err
+	}
+	var nodes []string
+	known := make(map[string]struct{})
+	for _, info := range announces {
+		if _, ok := known[info.Url]; ok {
+			continue
+		}
+		known[info.Url] = struct{}{}
+		nodes = append(nodes, info.Url)
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	c.SetNodes(nodes)
+	return nil
+}
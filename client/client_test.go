@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/internal/memory"
+	"soroban/p2p"
+	"soroban/server"
+	"soroban/services"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/gorilla/rpc"
+	"golang.org/x/crypto/nacl/sign"
+)
+
+// newTestServer start an in-process soroban json-rpc server with a memory directory
+func newTestServer(t *testing.T) *httptest.Server {
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterCodec(server.NewCodec(), "application/json")
+	err := rpcServer.RegisterService(new(services.Directory), "directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	ts := httptest.NewUnstartedServer(server.NewRPCHandler(rpcServer))
+	ts.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		ctx = context.WithValue(ctx, internal.SorobanDirectoryKey, directory)
+		return context.WithValue(ctx, internal.SorobanP2PKey, &p2p.P2P{})
+	}
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient(t *testing.T) {
+	ts := newTestServer(t)
+
+	// first node is down
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var dials atomic.Int32
+	dialer := &net.Dialer{}
+	c, err := New([]string{down.URL, ts.URL + "/"},
+		WithRetries(1, time.Millisecond),
+		WithDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+			dials.Add(1)
+			return dialer.DialContext(ctx, network, address)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	announce, _ := json.Marshal(services.AnnounceInfo{Version: "test", Url: ts.URL})
+	err = c.Add(ctx, services.DirectoryEntry{Name: "soroban.announce", Entry: string(announce), Mode: "short"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if dials.Load() < 2 {
+		t.Errorf("dialer not used")
+	}
+
+	err = c.UpdateNodes(ctx, "soroban.announce")
+	if err != nil {
+		t.Fatalf("UpdateNodes() error = %v", err)
+	}
+	if nodes := c.Nodes(); len(nodes) != 1 || nodes[0] != ts.URL {
+		t.Errorf("Nodes() = %v", nodes)
+	}
+
+	err = c.Remove(ctx, services.DirectoryEntry{Name: "soroban.announce", Entry: string(announce)})
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	response, err := c.List(ctx, services.DirectoryEntries{Name: "soroban.announce"})
+	if err != nil || len(response.Entries) != 0 {
+		t.Errorf("List() = %v, %v", response.Entries, err)
+	}
+
+	err = c.Add(ctx, services.DirectoryEntry{Name: "soroban.wait", Entry: "value", Mode: "short"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	response, err = c.Wait(ctx, services.DirectoryWait{Name: "soroban.wait", Timeout: 1})
+	if err != nil || len(response.Entries) != 1 || response.Entries[0] != "value" {
+		t.Errorf("Wait() = %v, %v", response.Entries, err)
+	}
+
+	_, err = c.List(ctx, services.DirectoryEntries{})
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidArgs {
+		t.Errorf("List() error = %v, want invalid args", err)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	failing := func(status int, body string) *httptest.Server {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	internalError := failing(http.StatusInternalServerError, "")
+	rateLimited := failing(http.StatusTooManyRequests, "")
+	replayed := failing(http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32007,"message":"Signature replayed"}}`)
+
+	tests := []struct {
+		name         string
+		node         *httptest.Server
+		method       string
+		calls        int32
+		maybeApplied bool
+	}{
+		{"read", internalError, "directory.List", 2, false},
+		{"write", internalError, "directory.Add", 1, true},
+		{"write-rate-limited", rateLimited, "directory.Add", 2, false},
+		{"write-replayed", replayed, "directory.Remove", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New([]string{tt.node.URL, tt.node.URL}, WithRetries(1, time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			calls.Store(0)
+			err = c.Call(context.Background(), tt.method, &services.DirectoryEntry{Name: "key"}, nil)
+			if err == nil {
+				t.Fatal("Call() expected error")
+			}
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("Call() calls = %d, want %d", got, tt.calls)
+			}
+			if got := errors.Is(err, ErrMaybeApplied); got != tt.maybeApplied {
+				t.Errorf("Call() error = %v, maybe applied %v, want %v", err, got, tt.maybeApplied)
+			}
+		})
+	}
+
+	// cursors are not sent to other nodes
+	c, err := New([]string{internalError.URL, rateLimited.URL}, WithRetries(1, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls.Store(0)
+	_, err = c.Wait(context.Background(), services.DirectoryWait{Name: "key", Cursor: "1"})
+	if err == nil || calls.Load() != 1 {
+		t.Errorf("Wait() error = %v, calls = %d, want 1 call", err, calls.Load())
+	}
+	if node := c.Node(); node != rateLimited.URL {
+		t.Errorf("Node() = %v, want next node %v", node, rateLimited.URL)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	naclPublic, naclPrivate, err := sign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPrivate, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	naclHex, err := NewSigner(confidential.AlgorithmNacl, hex.EncodeToString(naclPrivate[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaHex, err := NewSigner(confidential.AlgorithmEcdsa, hex.EncodeToString(ecdsaPrivate.Serialize()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testnet3, err := NewBitcoinSigner(confidential.AlgorithmTestnet3, "L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainnet, err := NewBitcoinSigner(confidential.AlgorithmMainnet, "L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer Signer
+	}{
+		{"nacl", NewNaclSigner(naclPublic, naclPrivate)},
+		{"ecdsa", NewEcdsaSigner(ecdsaPrivate)},
+		{"nacl-hex", naclHex},
+		{"ecdsa-hex", ecdsaHex},
+		{"testnet3", testnet3},
+		{"mainnet", mainnet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := confidential.ConfidentialEntry{
+				Prefix:    "soroban.readonly.*",
+				Algorithm: tt.signer.Algorithm(),
+				PublicKey: tt.signer.PublicKey(),
+				ReadOnly:  true,
+			}
+
+			args := services.DirectoryEntry{Name: "soroban.readonly.key", Entry: "value"}
+			err := SignEntry(tt.signer, &args, time.Now())
+			if err != nil {
+				t.Fatalf("SignEntry() error = %v", err)
+			}
+			if err := args.VerifySignature("directory.Add", info); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+
+			args.Entry = "other"
+			if err := args.VerifySignature("directory.Add", info); err != common.SignatureErr {
+				t.Errorf("VerifySignature() error = %v, want %v", err, common.SignatureErr)
+			}
+
+			// v2 signatures cover operation & mode
+			args = services.DirectoryEntry{Name: "soroban.readonly.key", Entry: "value", Mode: "short"}
+			err = SignEntryV2(tt.signer, confidential.Domain(), "directory.Add", &args, time.Now())
+			if err != nil {
+				t.Fatalf("SignEntryV2() error = %v", err)
+			}
+			if err := args.VerifySignature("directory.Add", info); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+			if err := args.VerifySignature("directory.Remove", info); err != common.SignatureErr {
+				t.Errorf("VerifySignature() error = %v, want %v", err, common.SignatureErr)
+			}
+			args.Mode = "long"
+			if err := args.VerifySignature("directory.Add", info); err != common.SignatureErr {
+				t.Errorf("VerifySignature() error = %v, want %v", err, common.SignatureErr)
+			}
+
+			entries := services.DirectoryEntries{Name: "soroban.readonly.key"}
+			err = SignEntries(tt.signer, &entries, time.Now())
+			if err != nil {
+				t.Fatalf("SignEntries() error = %v", err)
+			}
+			if err := entries.VerifySignature("directory.List", info); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+			// list signatures can't pop entries
+			if err := entries.VerifySignature("directory.Pop", info); err != common.SignatureErr {
+				t.Errorf("VerifySignature() error = %v, want %v", err, common.SignatureErr)
+			}
+			err = SignPop(tt.signer, &entries, time.Now())
+			if err != nil {
+				t.Fatalf("SignPop() error = %v", err)
+			}
+			if err := entries.VerifySignature("directory.Pop", info); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+
+			err = SignEntriesV2(tt.signer, "other.domain", "directory.List", &entries, time.Now())
+			if err != nil {
+				t.Fatalf("SignEntriesV2() error = %v", err)
+			}
+			if err := entries.VerifySignature("directory.List", info); err != common.SignatureErr {
+				t.Errorf("VerifySignature() error = %v, want %v", err, common.SignatureErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"soroban/confidential"
+	"soroban/services"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"golang.org/x/crypto/nacl/sign"
+)
+
+const (
+	bitcoinMagicMessage = "Bitcoin Signed Message:\n"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown signature algorithm")
+	ErrInvalidKey       = errors.New("invalid private key")
+)
+
+// Signer sign messages for confidential & readonly keys
+type Signer interface {
+	// Algorithm is one of confidential.Algorithms
+	Algorithm() string
+	// PublicKey as configured for the key prefix
+	PublicKey() string
+	// Sign return the encoded signature of message
+	Sign(message string) (string, error)
+}
+
+type naclSigner struct {
+	publicKey  *[32]byte
+	privateKey *[64]byte
+}
+
+// NewNaclSigner return a nacl signer, signatures are hex encoded.
+func NewNaclSigner(publicKey *[32]byte, privateKey *[64]byte) Signer {
+	return &naclSigner{
+		publicKey:  publicKey,
+		privateKey: privateKey,
+	}
+}
+
+func (p *naclSigner) Algorithm() string {
+	return confidential.AlgorithmNacl
+}
+
+func (p *naclSigner) PublicKey() string {
+	return hex.EncodeToString(p.publicKey[:])
+}
+
+func (p *naclSigner) Sign(message string) (string, error) {
+	signed := sign.Sign(nil, []byte(message), p.privateKey)
+	return hex.EncodeToString(signed[:sign.Overhead]), nil
+}
+
+type ecdsaSigner struct {
+	privateKey *btcec.PrivateKey
+}
+
+// NewEcdsaSigner return an ecdsa signer of message double sha256.
+// Public key is hex encoded compressed, signatures are hex encoded DER.
+func NewEcdsaSigner(privateKey *btcec.PrivateKey) Signer {
+	return &ecdsaSigner{
+		privateKey: privateKey,
+	}
+}
+
+func (p *ecdsaSigner) Algorithm() string {
+	return confidential.AlgorithmEcdsa
+}
+
+func (p *ecdsaSigner) PublicKey() string {
+	return hex.EncodeToString(p.privateKey.PubKey().SerializeCompressed())
+}
+
+func (p *ecdsaSigner) Sign(message string) (string, error) {
+	signature := ecdsa.Sign(p.privateKey, chainhash.DoubleHashB([]byte(message)))
+	return hex.EncodeToString(signature.Serialize()), nil
+}
+
+type bitcoinSigner struct {
+	algorithm string
+	address   string
+	wif       *btcutil.WIF
+}
+
+// NewBitcoinSigner return a bitcoin message signer for testnet3 or mainnet algorithm.
+// Public key is the P2PKH address of the WIF private key, signatures are base64 compact signatures.
+func NewBitcoinSigner(algorithm string, privateKey string) (Signer, error) {
+	var params *chaincfg.Params
+	switch algorithm {
+	case confidential.AlgorithmTestnet3:
+		params = &chaincfg.TestNet3Params
+	case confidential.AlgorithmMainnet:
+		params = &chaincfg.MainNetParams
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+
+	wif, err := btcutil.DecodeWIF(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(wif.SerializePubKey()), params)
+	if err != nil {
+		return nil, err
+	}
+	return &bitcoinSigner{
+		algorithm: algorithm,
+		address:   address.EncodeAddress(),
+		wif:       wif,
+	}, nil
+}
+
+func (p *bitcoinSigner) Algorithm() string {
+	return p.algorithm
+}
+
+func (p *bitcoinSigner) PublicKey() string {
+	return p.address
+}
+
+func (p *bitcoinSigner) Sign(message string) (string, error) {
+	var buf bytes.Buffer
+	err := wire.WriteVarString(&buf, 0, bitcoinMagicMessage)
+	if err != nil {
+		return "", err
+	}
+	err = wire.WriteVarString(&buf, 0, message)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := ecdsa.SignCompact(p.wif.PrivKey, chainhash.DoubleHashB(buf.Bytes()), p.wif.CompressPubKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// NewSigner return signer for algorithm from encoded private key.
+// nacl: hex 64 bytes private key, ecdsa: hex 32 bytes private key, testnet3 & mainnet: WIF.
+func NewSigner(algorithm, privateKey string) (Signer, error) {
+	privateKey = strings.TrimSpace(privateKey)
+	switch algorithm {
+	case confidential.AlgorithmNacl:
+		key, err := hex.DecodeString(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 64 {
+			return nil, ErrInvalidKey
+		}
+		var publicKey [32]byte
+		var private [64]byte
+		copy(private[:], key)
+		copy(publicKey[:], key[32:])
+		return NewNaclSigner(&publicKey, &private), nil
+
+	case confidential.AlgorithmEcdsa:
+		key, err := hex.DecodeString(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 32 {
+			return nil, ErrInvalidKey
+		}
+		private, _ := btcec.PrivKeyFromBytes(key)
+		return NewEcdsaSigner(private), nil
+
+	default:
+		return NewBitcoinSigner(algorithm, privateKey)
+	}
+}
+
+// SignEntries sign List & Wait requests with v1 messages
+func SignEntries(signer Signer, args *services.DirectoryEntries, timestamp time.Time) error {
+	args.SignatureVersion = 0
+	return signEntries(signer, "", "", args, timestamp)
+}
+
+// SignPop sign Pop requests with v1 messages
+func SignPop(signer Signer, args *services.DirectoryEntries, timestamp time.Time) error {
+	args.SignatureVersion = 0
+	return signEntries(signer, "", "directory.Pop", args, timestamp)
+}
+
+// SignEntriesV2 sign operation (ie directory.List) of domain with v2 canonical messages
+func SignEntriesV2(signer Signer, domain, operation string, args *services.DirectoryEntries, timestamp time.Time) error {
+	args.SignatureVersion = confidential.SignatureV2
+	return signEntries(signer, domain, operation, args, timestamp)
+}
+
+func signEntries(signer Signer, domain, operation string, args *services.DirectoryEntries, timestamp time.Time) error {
+	args.PublicKey = signer.PublicKey()
+	args.Algorithm = signer.Algorithm()
+	args.Timestamp = timestamp.UnixNano()
+
+	message, err := args.SignedMessage(operation, domain)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(message)
+	if err != nil {
+		return err
+	}
+	args.Signature = signature
+	return nil
+}
+
+// SignEntry sign Add & Remove requests with v1 messages
+func SignEntry(signer Signer, args *services.DirectoryEntry, timestamp time.Time) error {
+	args.SignatureVersion = 0
+	return signEntry(signer, "", "", args, timestamp)
+}
+
+// SignEntryV2 sign operation (ie directory.Add) of domain with v2 canonical messages, Mode is signed.
+func SignEntryV2(signer Signer, domain, operation string, args *services.DirectoryEntry, timestamp time.Time) error {
+	args.SignatureVersion = confidential.SignatureV2
+	return signEntry(signer, domain, operation, args, timestamp)
+}
+
+func signEntry(signer Signer, domain, operation string, args *services.DirectoryEntry, timestamp time.Time) error {
+	args.PublicKey = signer.PublicKey()
+	args.Algorithm = signer.Algorithm()
+	args.Timestamp = timestamp.UnixNano()
+
+	message, err := args.SignedMessage(operation, domain)
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(message)
+	if err != nil {
+		return err
+	}
+	args.Signature = signature
+	return nil
+}
@@ -14,6 +14,29 @@ class RPCCallError extends Error {
   }
 }
 
+/**
+ * JSON-RPC error codes returned by Soroban nodes
+ */
+const RPCErrorCode = {
+  INVALID_ARGS: -32602,
+  INTERNAL: -32603,
+  INVALID_SIGNATURE: -32001,
+  TIMESTAMP_OUT_OF_RANGE: -32002,
+  FORBIDDEN: -32003,
+  QUOTA_EXCEEDED: -32004,
+  RATE_LIMITED: -32005,
+  INVALID_STAMP: -32006,
+  SIGNATURE_REPLAYED: -32007,
+}
+
+class RPCError extends Error {
+  constructor(code, message) {
+    super(message)
+    this.name = "RPCError"
+    this.code = code
+  }
+}
+
 async function encodeDirectory(name) {
   if (name == undefined || name == null || name == '')
     throw Error('encodeDirectory Invalid name')
@@ -31,13 +54,6 @@ async function encodeDirectory(name) {
   return hashHex;
 }
 
-function delay(milliseconds){
-  return new Promise(resolve => {
-    setTimeout(resolve, milliseconds);
-  })
-}
-
-
 class RPC {
 
     /**
@@ -58,6 +74,7 @@ class RPC {
      * @returns 
      */
     async _call(method, args) {
+      let responseObj = null
       try {
         const payload = {
           'method': method,
@@ -84,13 +101,20 @@ class RPC {
         if (!response.ok) 
           throw new Error(`Received invalid status from RPC API: ${response.status}`)
 
-        const responseObj = await response.json()
-
-        return Object.hasOwn(responseObj, 'result') ? responseObj.result : null
+        responseObj = await response.json()
 
       } catch (e) {
         throw new RPCCallError(`RPC error: ${method}: ${e.cause}`)
       }
+
+      if (responseObj.error != null) {
+        const error = responseObj.error
+        if (typeof error == 'object')
+          throw new RPCError(error.code, `${method}: ${error.message}`)
+        throw new RPCError(RPCErrorCode.INTERNAL, `${method}: ${error}`)
+      }
+
+      return Object.hasOwn(responseObj, 'result') ? responseObj.result : null
     }
 
     /**
@@ -123,33 +147,60 @@ class RPC {
       return !(resp == null || !Object.hasOwn(resp, 'Status') || resp.Status != 'success')
     }
 
+    /**
+     * WebSocket URL of the Soroban node subscription endpoint
+     * @returns {string}
+     */
+    _subscribeUrl() {
+      const url = new URL(this.url)
+      url.protocol = (url.protocol == 'https:') ? 'wss:' : 'ws:'
+      url.pathname = url.pathname.replace(/rpc$/, 'ws')
+      return url.toString()
+    }
+
+    /**
+     * Wait for the first entry pushed for a given key
+     * @param {string} directory
+     * @param {int} timeout in milliseconds
+     * @returns {string}
+     */
+    directoryWait(directory, timeout) {
+      return new Promise((resolve, reject) => {
+        const socket = new WebSocket(this._subscribeUrl())
+        const timer = setTimeout(() => {
+          socket.close()
+          reject(new RPCTimeoutError(`Wait on ${directory}`))
+        }, timeout)
+
+        socket.onopen = () => {
+          socket.send(JSON.stringify({'Action': 'subscribe', 'Name': directory}))
+        }
+        socket.onmessage = (message) => {
+          const event = JSON.parse(message.data)
+          if (event.Event == 'error') {
+            clearTimeout(timer)
+            socket.close()
+            reject(new RPCCallError(`Subscribe error: ${event.Error}`))
+          } else if (event.Event == 'add') {
+            clearTimeout(timer)
+            socket.close()
+            resolve(event.Entry)
+          }
+        }
+        socket.onerror = () => {
+          clearTimeout(timer)
+          reject(new RPCCallError(`Subscribe error: ${directory}`))
+        }
+      })
+    }
+
     /**
      * Wait for an entry associated to a given key
      * @param {*} directory 
-     * @param {*} count 
+     * @param {*} count number of 200ms periods to wait
      */
     async waitAndRemove(directory, count=25) {
-      let values = [],
-          total = count
-      
-      count = 0
-
-      while (count < total) {
-        values = await this.directoryList(directory)
-        count += 1
-        if (values.length > 0 || count >= total) {
-          break
-        }
-        // Wait for next list
-        await delay(200)
-      }
-
-      if (count >= total) {
-        throw new RPCTimeoutError(`Wait on ${directory}`)
-      }
-
-      // Consider last entry
-      const value = values[values.length - 1]
+      const value = await this.directoryWait(directory, count * 200)
       await this.directoryRemove(directory, value)
       return value
     }
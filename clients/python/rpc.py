@@ -6,6 +6,22 @@ class RpcTimeout(Exception):
 class RpcCall(Exception):
     pass
 
+# JSON-RPC error codes returned by soroban nodes
+ERROR_INVALID_ARGS = -32602
+ERROR_INTERNAL = -32603
+ERROR_INVALID_SIGNATURE = -32001
+ERROR_TIMESTAMP_OUT_OF_RANGE = -32002
+ERROR_FORBIDDEN = -32003
+ERROR_QUOTA_EXCEEDED = -32004
+ERROR_RATE_LIMITED = -32005
+ERROR_INVALID_STAMP = -32006
+ERROR_SIGNATURE_REPLAYED = -32007
+
+class RpcError(Exception):
+    def __init__(self, code, message):
+        super().__init__(message)
+        self.code = code
+
 def get_session(with_tor):
     session = requests.session()
     if with_tor:
@@ -37,10 +53,16 @@ class Rpc:
                 "id": 1,
             }
             response = self.session.post(self.url, data=json.dumps(payload), headers=headers).json()
-            return response['result']
         except:
             raise RpcCall("RPC error: %s" % method)
 
+        error = response.get('error')
+        if error is not None:
+            if isinstance(error, dict):
+                raise RpcError(error.get('code', ERROR_INTERNAL), "%s: %s" % (method, error.get('message', '')))
+            raise RpcError(ERROR_INTERNAL, "%s: %s" % (method, error))
+        return response.get('result')
+
 
     def directory_list(self, name):
         resp = self.call('directory.List', {'Name': name, 'Entries': []})
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	soroban "soroban"
+	"soroban/internal/version"
 	"soroban/server"
 
 	"soroban/services"
@@ -30,7 +31,7 @@ var (
 func init() {
 	rand.Seed(time.Now().UnixNano())
 
-	version := flag.Bool("version", false, "Print version and exit")
+	printVersion := flag.Bool("version", false, "Print version and exit")
 	flag.StringVar(&options.LogLevel, "log", options.LogLevel, "Log level (default info)")
 	flag.StringVar(&options.LogFile, "logfile", options.LogFile, "Log file (default -)")
 
@@ -50,11 +51,26 @@ func init() {
 	flag.StringVar(&options.Soroban.Hostname, "hostname", options.Soroban.Hostname, "server address (default localhost)")
 	flag.IntVar(&options.Soroban.Port, "port", options.Soroban.Port, "Server port (default 4242)")
 
-	flag.StringVar(&options.Soroban.DirectoryType, "directoryType", options.Soroban.DirectoryType, "Directory Type (default, redis, memory)")
+	flag.StringVar(&options.Soroban.DirectoryType, "directoryType", options.Soroban.DirectoryType, "Directory Type (default, redis, memory, disk)")
+	flag.StringVar(&options.Soroban.RedisURL, "redisURL", options.Soroban.RedisURL, "Redis URL for redis directory")
+	flag.StringVar(&options.Soroban.DataDir, "dataDir", options.Soroban.DataDir, "Data directory for disk directory")
+	flag.StringVar(&options.Soroban.Snapshot, "snapshot", options.Soroban.Snapshot, "Snapshot file for memory directory (snapshot disabled if empty)")
+	flag.IntVar(&options.Soroban.MaxKeyLength, "maxKeyLength", options.Soroban.MaxKeyLength, "Max directory key length (0 or -1 for no limits)")
+	flag.IntVar(&options.Soroban.MaxEntrySize, "maxEntrySize", options.Soroban.MaxEntrySize, "Max directory entry size (0 or -1 for no limits)")
+	flag.IntVar(&options.Soroban.MaxValues, "maxValues", options.Soroban.MaxValues, "Max values per directory key (0 or -1 for no limits)")
+	flag.StringVar(&options.Soroban.EvictionPolicy, "evictionPolicy", options.Soroban.EvictionPolicy, "Policy when directory key is full (reject, oldest, soonest)")
+	flag.IntVar(&options.Soroban.RateLimit, "rateLimit", options.Soroban.RateLimit, "Max requests per minute, listener and IP (0 or -1 for no limits)")
+	flag.IntVar(&options.Soroban.RateLimitAdd, "rateLimitAdd", options.Soroban.RateLimitAdd, "Max write requests per minute, listener, IP and key (0 or -1 for no limits)")
+	flag.IntVar(&options.Soroban.RateLimitList, "rateLimitList", options.Soroban.RateLimitList, "Max read requests per minute, listener, IP and key (0 or -1 for no limits)")
 	flag.StringVar(&options.Soroban.Announce, "announce", options.Soroban.Announce, "Soroban key for node annouce")
 
 	flag.StringVar(&options.Soroban.StatsEndpoint, "statsEndpoint", options.Soroban.StatsEndpoint, "Label of the RPC API /stats endpoint (endpoint deactivated if empty label)")
 	flag.StringVar(&options.Soroban.StatusEndpoint, "statusEndpoint", options.Soroban.StatusEndpoint, "Label of the RPC API /status endpoint (enpoint deactivated if empty label)")
+	flag.StringVar(&options.Soroban.AdminListen, "adminListen", options.Soroban.AdminListen, "Admin RPC listen address, localhost:port or unix:/path (admin listener deactivated if empty)")
+	flag.StringVar(&options.Soroban.AdminPublicKey, "adminPublicKey", options.Soroban.AdminPublicKey, "Public key required to sign admin requests")
+	flag.StringVar(&options.Soroban.AdminAlgorithm, "adminAlgorithm", options.Soroban.AdminAlgorithm, "Signature algorithm of admin public key (nacl, ecdsa)")
+	flag.StringVar(&options.Soroban.AuditLog, "auditLog", options.Soroban.AuditLog, "Admin audit log file (default to log)")
+	flag.StringVar(&options.Soroban.MetricsEndpoint, "metricsEndpoint", options.Soroban.MetricsEndpoint, "Label of the prometheus /metrics endpoint (endpoint deactivated if empty label)")
 
 	flag.StringVar(&options.P2P.Seed, "p2pSeed", options.P2P.Seed, "P2P Onion private key seed")
 	flag.StringVar(&options.P2P.Bootstrap, "p2pBootstrap", options.P2P.Bootstrap, "P2P bootstrap")
@@ -82,8 +98,8 @@ func init() {
 
 	flag.Parse()
 
-	if *version {
-		printVersionExit()
+	if *printVersion {
+		version.PrintExit()
 	}
 	options.Load(options.Soroban.Config)
 
@@ -150,7 +166,7 @@ func run() error {
 		return nil
 	}
 
-	err := services.RegisterAll(ctx, sorobanServer)
+	err := services.RegisterAll(ctx, sorobanServer, options, version.Version)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
@@ -191,12 +207,12 @@ func run() error {
 		}
 
 		go services.StartAnnounce(ctx, options.Soroban.Announce,
-			Version,
+			version.Version,
 			announces...,
 		)
 	}
 
-	<-ctx.Done()
+	WaitForExit(ctx)
 	return nil
 }
 
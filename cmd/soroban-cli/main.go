@@ -0,0 +1,512 @@
+// soroban-cli is a command line client for soroban nodes
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"soroban/client"
+	"soroban/confidential"
+	"soroban/internal/version"
+	"soroban/services"
+)
+
+const (
+	EnvURL       = "SOROBAN_URL"
+	EnvSocks     = "SOROBAN_SOCKS"
+	EnvKey       = "SOROBAN_KEY"
+	EnvAlgorithm = "SOROBAN_ALGORITHM"
+	EnvDomain    = "SOROBAN_DOMAIN"
+
+	DefaultURL      = "http://localhost:4242"
+	DefaultTorSocks = "127.0.0.1:9050"
+
+	OutputLines = "lines"
+	OutputJSON  = "json"
+
+	// watchTimeoutMargin is added to wait timeout for requests timeout, responses are sent on wait timeout
+	watchTimeoutMargin = 10 * time.Second
+)
+
+var (
+	ErrUsage = errors.New("invalid arguments")
+)
+
+// globalOptions are shared by all commands
+type globalOptions struct {
+	urls      string
+	socks     string
+	keyFile   string
+	algorithm string
+	output    string
+	timeout   time.Duration
+	retries   int
+
+	domain           string
+	signatureVersion int
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]*command{
+	"list":   {usage: "list [-limit n] NAME", run: runList},
+	"add":    {usage: "add [-mode mode] [-difficulty n] NAME ENTRY", run: runAdd},
+	"remove": {usage: "remove NAME ENTRY", run: runRemove},
+	"watch":  {usage: "watch [-wait seconds] NAME", run: runWatch},
+	"sign":   {usage: "sign [-timestamp ns] [-operation op] [-mode mode] NAME [ENTRY]", run: runSign},
+	"stats":  {usage: "stats [-path /stats]", run: runGet("/stats")},
+	"status": {usage: "status [-path /status]", run: runGet("/status")},
+}
+
+var (
+	listLimit      int
+	addMode        string
+	addDifficulty  int
+	watchTimeout   int
+	signTimestamp  int64
+	signOperation  string
+	signMode       string
+	getPath        string
+	commandFlagSet = map[string]func(flags *flag.FlagSet){
+		"list": func(flags *flag.FlagSet) {
+			flags.IntVar(&listLimit, "limit", 0, "Limit returned entries (0 for all)")
+		},
+		"add": func(flags *flag.FlagSet) {
+			flags.StringVar(&addMode, "mode", "default", "Entry time to live mode (fast, short, normal, default, long)")
+			flags.IntVar(&addDifficulty, "difficulty", 0, "Mint a proof of work stamp with difficulty leading zero bits")
+		},
+		"watch": func(flags *flag.FlagSet) {
+			flags.IntVar(&watchTimeout, "wait", int(services.DefaultWaitTimeout/time.Second), "Wait timeout in seconds of each request")
+		},
+		"sign": func(flags *flag.FlagSet) {
+			flags.Int64Var(&signTimestamp, "timestamp", 0, "Signature timestamp in nanoseconds (default now)")
+			flags.StringVar(&signOperation, "operation", "", "Signed operation for v2 signatures (default directory.List, or directory.Add with ENTRY)")
+			flags.StringVar(&signMode, "mode", "", "Signed entry mode for v2 signatures")
+		},
+		"stats": func(flags *flag.FlagSet) {
+			flags.StringVar(&getPath, "path", "/stats", "Path of the stats endpoint, as configured with server -statsEndpoint")
+		},
+		"status": func(flags *flag.FlagSet) {
+			flags.StringVar(&getPath, "path", "/status", "Path of the status endpoint, as configured with server -statusEndpoint")
+		},
+	}
+)
+
+func main() {
+	opts := globalOptions{
+		urls:      env(EnvURL, DefaultURL),
+		socks:     os.Getenv(EnvSocks),
+		algorithm: env(EnvAlgorithm, confidential.AlgorithmEcdsa),
+		output:    OutputLines,
+		timeout:   client.DefaultTimeout,
+		retries:   client.DefaultRetries,
+
+		domain:           env(EnvDomain, confidential.DefaultDomain),
+		signatureVersion: confidential.SignatureV1,
+	}
+
+	flags := flag.NewFlagSet("soroban-cli", flag.ExitOnError)
+	printVersion := flags.Bool("version", false, "Print version and exit")
+	flags.StringVar(&opts.urls, "url", opts.urls, "Comma separated soroban nodes urls (env "+EnvURL+")")
+	flags.StringVar(&opts.socks, "socks", opts.socks, "SOCKS5 proxy address, "+DefaultTorSocks+" for onion urls if not set (env "+EnvSocks+")")
+	flags.StringVar(&opts.keyFile, "key", opts.keyFile, "Private key file, key is read from env "+EnvKey+" if not set")
+	flags.StringVar(&opts.algorithm, "algorithm", opts.algorithm, "Signature algorithm (nacl, ecdsa, testnet3, mainnet) (env "+EnvAlgorithm+")")
+	flags.StringVar(&opts.output, "output", opts.output, "Output format (lines, json)")
+	flags.DurationVar(&opts.timeout, "timeout", opts.timeout, "Requests timeout")
+	flags.IntVar(&opts.retries, "retries", opts.retries, "Retries on other nodes")
+	flags.StringVar(&opts.domain, "domain", opts.domain, "Nodes domain, signed by v2 signatures (env "+EnvDomain+")")
+	flags.IntVar(&opts.signatureVersion, "signatureVersion", opts.signatureVersion, "Signature version (1, 2)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: soroban-cli [options] command [command options] args\n\nCommands:\n")
+		for _, name := range []string{"list", "add", "remove", "watch", "sign", "stats", "status"} {
+			fmt.Fprintf(flags.Output(), "  %s\n", commands[name].usage)
+		}
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if *printVersion {
+		version.PrintExit()
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", name)
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cmdFlags := flag.NewFlagSet(name, flag.ExitOnError)
+	if setup, ok := commandFlagSet[name]; ok {
+		setup(cmdFlags)
+	}
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(cmdFlags.Output(), "Usage: soroban-cli [options] %s\n", cmd.usage)
+		cmdFlags.PrintDefaults()
+	}
+	cmdFlags.Parse(flags.Args()[1:])
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	err := cmd.run(ctx, &opts, cmdFlags, cmdFlags.Args())
+	if errors.Is(err, ErrUsage) {
+		cmdFlags.Usage()
+		os.Exit(2)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func env(name, value string) string {
+	if v, ok := os.LookupEnv(name); ok && len(v) > 0 {
+		return v
+	}
+	return value
+}
+
+// newClient return a client for nodes urls, onion urls use Tor SOCKS proxy by default.
+func (p *globalOptions) newClient() (*client.Client, error) {
+	var nodes []string
+	socks := p.socks
+	for _, node := range strings.Split(p.urls, ",") {
+		node = strings.TrimSpace(node)
+		if len(node) == 0 {
+			continue
+		}
+		if !strings.Contains(node, "://") {
+			node = "http://" + node
+		}
+		u, err := url.Parse(node)
+		if err != nil {
+			return nil, err
+		}
+		if len(socks) == 0 && strings.HasSuffix(u.Hostname(), ".onion") {
+			socks = DefaultTorSocks
+		}
+		nodes = append(nodes, node)
+	}
+
+	options := []client.Option{
+		client.WithTimeout(p.timeout),
+		client.WithRetries(p.retries, client.DefaultRetryDelay),
+	}
+	if len(socks) > 0 {
+		options = append(options, client.WithSOCKS5(socks))
+	}
+	return client.New(nodes, options...)
+}
+
+// signer return signer from key file or environment, nil if no key is set.
+func (p *globalOptions) signer() (client.Signer, error) {
+	key := os.Getenv(EnvKey)
+	if len(p.keyFile) > 0 {
+		data, err := os.ReadFile(p.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = string(data)
+	}
+	key = strings.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, nil
+	}
+	return client.NewSigner(p.algorithm, key)
+}
+
+// requireSigner return signer or an error if no key is set.
+func (p *globalOptions) requireSigner() (client.Signer, error) {
+	signer, err := p.signer()
+	if err != nil {
+		return nil, err
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("private key required, use -key or %s", EnvKey)
+	}
+	return signer, nil
+}
+
+// signEntries sign List, Pop & Wait requests with the selected signature version
+func (p *globalOptions) signEntries(signer client.Signer, operation string, args *services.DirectoryEntries, timestamp time.Time) error {
+	if p.signatureVersion == confidential.SignatureV2 {
+		return client.SignEntriesV2(signer, p.domain, operation, args, timestamp)
+	}
+	if operation == "directory.Pop" {
+		return client.SignPop(signer, args, timestamp)
+	}
+	return client.SignEntries(signer, args, timestamp)
+}
+
+// signEntry sign Add & Remove requests with the selected signature version
+func (p *globalOptions) signEntry(signer client.Signer, operation string, args *services.DirectoryEntry, timestamp time.Time) error {
+	if p.signatureVersion == confidential.SignatureV2 {
+		return client.SignEntryV2(signer, p.domain, operation, args, timestamp)
+	}
+	return client.SignEntry(signer, args, timestamp)
+}
+
+// print write result as json or entries one per line
+func (p *globalOptions) print(result interface{}, lines []string) error {
+	if p.output == OutputJSON {
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runList(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+	request := services.DirectoryEntries{
+		Name:  args[0],
+		Limit: listLimit,
+	}
+	signer, err := opts.signer()
+	if err != nil {
+		return err
+	}
+	if signer != nil {
+		err = opts.signEntries(signer, "directory.List", &request, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	response, err := c.List(ctx, request)
+	if err != nil {
+		return err
+	}
+	return opts.print(response, response.Entries)
+}
+
+// directoryEntry return signed entry if a key is set
+func directoryEntry(opts *globalOptions, operation string, args []string, mode string) (services.DirectoryEntry, error) {
+	if len(args) != 2 {
+		return services.DirectoryEntry{}, ErrUsage
+	}
+	entry := services.DirectoryEntry{
+		Name:      args[0],
+		Entry:     args[1],
+		Mode:      mode,
+		Timestamp: time.Now().UnixNano(),
+	}
+	signer, err := opts.signer()
+	if err != nil {
+		return entry, err
+	}
+	if signer != nil {
+		err = opts.signEntry(signer, operation, &entry, time.Now())
+	}
+	return entry, err
+}
+
+func runAdd(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+	entry, err := directoryEntry(opts, "directory.Add", args, addMode)
+	if err != nil {
+		return err
+	}
+	if addDifficulty > 0 {
+		entry.Nonce, err = confidential.MintStamp(ctx, entry.Name, entry.Entry, entry.Timestamp, addDifficulty)
+		if err != nil {
+			return err
+		}
+	}
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+	err = c.Add(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return opts.print(services.Response{Status: "success"}, []string{"success"})
+}
+
+func runRemove(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+	entry, err := directoryEntry(opts, "directory.Remove", args, "")
+	if err != nil {
+		return err
+	}
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+	err = c.Remove(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return opts.print(services.Response{Status: "success"}, []string{"success"})
+}
+
+// runWatch print entries added to key until interrupted.
+// Requests are signed again before each wait for confidential keys.
+// Cursor is dropped when node changes, printed entries are sent as known entries to the next node.
+func runWatch(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	wait := services.DefaultWaitTimeout
+	if watchTimeout > 0 {
+		wait = time.Duration(watchTimeout) * time.Second
+	}
+	if opts.timeout < wait+watchTimeoutMargin {
+		opts.timeout = wait + watchTimeoutMargin
+	}
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+	signer, err := opts.signer()
+	if err != nil {
+		return err
+	}
+
+	request := services.DirectoryWait{
+		Name:    args[0],
+		Timeout: watchTimeout,
+	}
+	var printed []string
+	var cursorNode string
+	for {
+		if len(request.Cursor) > 0 && c.Node() != cursorNode {
+			request.Cursor = ""
+			request.Known = printed
+		}
+		if signer != nil {
+			var signed services.DirectoryEntries
+			signed.Name = request.Name
+			err = opts.signEntries(signer, "directory.Wait", &signed, time.Now())
+			if err != nil {
+				return err
+			}
+			request.PublicKey = signed.PublicKey
+			request.Algorithm = signed.Algorithm
+			request.Signature = signed.Signature
+			request.Timestamp = signed.Timestamp
+			request.SignatureVersion = signed.SignatureVersion
+		}
+
+		node := c.Node()
+		response, err := c.Wait(ctx, request)
+		if err != nil {
+			if len(request.Cursor) > 0 && c.Node() != node {
+				continue
+			}
+			return err
+		}
+		if len(response.Entries) > 0 {
+			err = opts.print(response, response.Entries)
+			if err != nil {
+				return err
+			}
+			printed = append(printed, response.Entries...)
+		}
+		if len(response.Cursor) > 0 {
+			request.Cursor = response.Cursor
+			request.Known = nil
+			cursorNode = c.Node()
+		} else {
+			request.Known = printed
+		}
+	}
+}
+
+// runSign print the signature expected by nodes for list (NAME) or add & remove (NAME ENTRY) requests.
+func runSign(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return ErrUsage
+	}
+	signer, err := opts.requireSigner()
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now()
+	if signTimestamp > 0 {
+		timestamp = time.Unix(0, signTimestamp)
+	}
+
+	var result interface{}
+	var signature string
+	operation := signOperation
+	if len(args) == 1 {
+		if len(operation) == 0 {
+			operation = "directory.List"
+		}
+		request := services.DirectoryEntries{Name: args[0]}
+		err = opts.signEntries(signer, operation, &request, timestamp)
+		result, signature = request, request.Signature
+	} else {
+		if len(operation) == 0 {
+			operation = "directory.Add"
+		}
+		request := services.DirectoryEntry{Name: args[0], Entry: args[1], Mode: signMode}
+		err = opts.signEntry(signer, operation, &request, timestamp)
+		result, signature = request, request.Signature
+	}
+	if err != nil {
+		return err
+	}
+	return opts.print(result, []string{
+		signer.PublicKey(),
+		fmt.Sprintf("%d", timestamp.UnixNano()),
+		signature,
+	})
+}
+
+// runGet print json response of /stats or /status endpoints
+func runGet(path string) func(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+	return func(ctx context.Context, opts *globalOptions, flags *flag.FlagSet, args []string) error {
+		if len(args) != 0 {
+			return ErrUsage
+		}
+		if len(getPath) > 0 {
+			path = getPath
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+		var result json.RawMessage
+		err = c.Get(ctx, path, &result)
+		if err != nil {
+			return err
+		}
+		if opts.output == OutputJSON {
+			_, err = fmt.Println(string(result))
+			return err
+		}
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Println(string(output))
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+package confidential
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// SignatureV1 messages are "Name.Timestamp" or "Name.Timestamp.Entry"
+	SignatureV1 = 1
+	// SignatureV2 messages are canonical messages of all request fields
+	SignatureV2 = 2
+
+	// CanonicalPrefixV2 is the first line of v2 canonical messages
+	CanonicalPrefixV2 = "soroban-signature-v2"
+
+	DefaultDomain = "soroban"
+)
+
+var (
+	signatureDomain       = DefaultDomain
+	signatureDomainLocker sync.Mutex
+)
+
+// SetDomain set the domain of v2 signatures, signatures of other soroban deployments are not valid
+func SetDomain(domain string) {
+	signatureDomainLocker.Lock()
+	defer signatureDomainLocker.Unlock()
+	if len(domain) == 0 {
+		domain = DefaultDomain
+	}
+	signatureDomain = domain
+}
+
+// Domain return the domain of v2 signatures
+func Domain() string {
+	signatureDomainLocker.Lock()
+	defer signatureDomainLocker.Unlock()
+	return signatureDomain
+}
+
+// CanonicalMessage return the v2 signed message.
+// Message is CanonicalPrefixV2 followed by one "length:value" line per field,
+// in order: operation, domain, name, entry, mode & timestamp (unix nano in decimal).
+// Length is the decimal byte length of value, missing fields are empty.
+func CanonicalMessage(operation, domain, name, entry, mode string, timestamp int64) string {
+	var sb strings.Builder
+	sb.WriteString(CanonicalPrefixV2)
+	for _, field := range []string{operation, domain, name, entry, mode, strconv.FormatInt(timestamp, 10)} {
+		sb.WriteByte('\n')
+		sb.WriteString(strconv.Itoa(len(field)))
+		sb.WriteByte(':')
+		sb.WriteString(field)
+	}
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+package confidential
+
+import (
+	"testing"
+)
+
+// v2 test vectors, keys:
+// nacl: seed of 32 0x01 bytes
+// ecdsa, testnet3 & mainnet: WIF L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF
+const (
+	canonicalVectorMessage = "soroban-signature-v2\n13:directory.Add\n7:soroban\n20:soroban.readonly.key\n5:value\n5:short\n19:1700000000000000000"
+	canonicalVectorV1      = "soroban.readonly.key.1700000000000000000.value"
+)
+
+func TestCanonicalMessage(t *testing.T) {
+	got := CanonicalMessage("directory.Add", "soroban", "soroban.readonly.key", "value", "short", 1700000000000000000)
+	if got != canonicalVectorMessage {
+		t.Errorf("CanonicalMessage() = %q, want %q", got, canonicalVectorMessage)
+	}
+
+	// fields can't be shifted
+	if CanonicalMessage("directory.Add", "soroban", "a.b", "", "", 1) == CanonicalMessage("directory.Add", "soroban", "a", "b", "", 1) {
+		t.Error("CanonicalMessage() fields are ambiguous")
+	}
+}
+
+func TestVerifySignature_CanonicalVectors(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		publicKey string
+		signature string
+	}{
+		{AlgorithmNacl, "8a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c", "a157a164876e95ebefcb470b2115db50a237abbd97bd709a9fc3397cdf7e1de7dffe880e11f31e5516932d135e8719aaf57e7c8f6b51047a6c3117af078a3c0c"},
+		{AlgorithmEcdsa, "024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "3045022100f7677a940eecc19320f0023314e6677971fe01acde164e36a13244e552e380de022060f6ceecf9dfc882a09b03962087dd88e5b0be579ff1447d78aca47ab6246d8a"},
+		{AlgorithmTestnet3, "mfxpswLES2MFsY2ThM1TyM2nNwGdw5kQKp", "HylxzF3TPErB+gMtVK1rtOfSIUB/WuhPv/KPI6T5niZCN//YZYeY2X4LqoYQKwZRsRakGz5D5y0jRX/iDb+ucBE="},
+		{AlgorithmMainnet, "1SsatFFczv16RYqyn369RpTWwfvwzyL88", "HylxzF3TPErB+gMtVK1rtOfSIUB/WuhPv/KPI6T5niZCN//YZYeY2X4LqoYQKwZRsRakGz5D5y0jRX/iDb+ucBE="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			info := ConfidentialEntry{
+				Prefix:    "soroban.readonly.*",
+				Algorithm: tt.algorithm,
+				PublicKey: tt.publicKey,
+				ReadOnly:  true,
+			}
+			if err := VerifySignature(info, tt.publicKey, canonicalVectorMessage, tt.algorithm, tt.signature); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+			if err := VerifySignature(info, tt.publicKey, canonicalVectorV1, tt.algorithm, tt.signature); err == nil {
+				t.Error("VerifySignature() v1 message expected error")
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v2"
@@ -20,14 +21,32 @@ const (
 	AlgorithmEcdsa    = "ecdsa"
 	AlgorithmTestnet3 = "testnet3"
 	AlgorithmMainnet  = "mainnet"
+
+	// DefaultTolerance is the default accepted signature timestamp skew
+	DefaultTolerance = 24 * time.Hour
 )
 
+// Algorithms supported by VerifySignature
+var Algorithms = []string{AlgorithmNacl, AlgorithmEcdsa, AlgorithmTestnet3, AlgorithmMainnet}
+
 type ConfidentialEntry struct {
 	Prefix       string `yaml:"prefix"`
 	Algorithm    string `yaml:"algorithm"`
 	PublicKey    string `yaml:"publickey"`
 	Confidential bool   `yaml:"confidential"`
 	ReadOnly     bool   `yaml:"readonly"`
+	// Difficulty is the proof of work required to add entries, in leading zero bits
+	Difficulty int `yaml:"difficulty"`
+	// Tolerance is the accepted signature timestamp skew, DefaultTolerance if not set
+	Tolerance time.Duration `yaml:"tolerance"`
+}
+
+// SignatureWindow return the accepted signature timestamp skew
+func (p ConfidentialEntry) SignatureWindow() time.Duration {
+	if p.Tolerance > 0 {
+		return p.Tolerance
+	}
+	return DefaultTolerance
 }
 
 type SorobanConfig struct {
@@ -62,6 +81,12 @@ func ConfigLoad(filename string) SorobanConfig {
 	return config
 }
 
+// ConfigReload load config file and return the count of confidential entries
+func ConfigReload(filename string) int {
+	DefaultSorobanConfig = ConfigLoad(filename)
+	return len(DefaultSorobanConfig.Confidential)
+}
+
 func ConfigWatcher(ctx context.Context, filename string) {
 	if len(filename) == 0 {
 		return // Noop
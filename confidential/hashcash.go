@@ -0,0 +1,61 @@
+package confidential
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+	"strconv"
+)
+
+// StampHash return the proof of work hash of an entry.
+// Fields are length prefixed, timestamp is unix nano.
+func StampHash(name, entry string, timestamp int64, nonce string) [sha256.Size]byte {
+	var buf [binary.MaxVarintLen64]byte
+	h := sha256.New()
+	for _, field := range []string{name, entry} {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(field)))])
+		h.Write([]byte(field))
+	}
+	binary.BigEndian.PutUint64(buf[:8], uint64(timestamp))
+	h.Write(buf[:8])
+	h.Write([]byte(nonce))
+
+	var result [sha256.Size]byte
+	copy(result[:], h.Sum(nil))
+	return result
+}
+
+// LeadingZeros return the count of leading zero bits of hash
+func LeadingZeros(hash []byte) int {
+	result := 0
+	for _, b := range hash {
+		if b != 0 {
+			return result + bits.LeadingZeros8(b)
+		}
+		result += 8
+	}
+	return result
+}
+
+// VerifyStamp check nonce hash has at least difficulty leading zero bits
+func VerifyStamp(name, entry string, timestamp int64, nonce string, difficulty int) bool {
+	hash := StampHash(name, entry, timestamp, nonce)
+	return LeadingZeros(hash[:]) >= difficulty
+}
+
+// MintStamp search a nonce for entry with difficulty leading zero bits.
+// Expected work is 2^difficulty hashes, search stops when ctx is done.
+func MintStamp(ctx context.Context, name, entry string, timestamp int64, difficulty int) (string, error) {
+	for counter := uint64(0); ; counter++ {
+		if counter%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		nonce := strconv.FormatUint(counter, 16)
+		if VerifyStamp(name, entry, timestamp, nonce, difficulty) {
+			return nonce, nil
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package confidential
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMintStamp(t *testing.T) {
+	const timestamp = int64(1700000000000000000)
+	tests := []struct {
+		name       string
+		difficulty int
+	}{
+		{"none", 0},
+		{"low", 4},
+		{"medium", 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce, err := MintStamp(context.Background(), "soroban.key", "value", timestamp, tt.difficulty)
+			if err != nil {
+				t.Fatalf("MintStamp() error = %v", err)
+			}
+			if !VerifyStamp("soroban.key", "value", timestamp, nonce, tt.difficulty) {
+				t.Errorf("VerifyStamp() = false, nonce %s", nonce)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MintStamp(ctx, "soroban.key", "value", timestamp, 256); err == nil {
+		t.Error("MintStamp() expected context error")
+	}
+}
@@ -20,7 +20,7 @@ import (
 func toNaclPubKey(publicKey string) *[32]byte {
 	var result [32]byte
 	key, err := hex.DecodeString(publicKey)
-	if err != nil {
+	if err != nil || len(key) < 32 {
 		return nil
 	}
 	copy(result[:], key[:32])
@@ -112,30 +112,35 @@ func signMessage(privateKey, message string) string {
 }
 
 func verifyNaclSignature(publicKey, message, signature string) bool {
+	key := toNaclPubKey(publicKey)
+	if key == nil {
+		return false
+	}
 	signedMessage, _ := hex.DecodeString(signature)
 	signedMessage = append(signedMessage, []byte(message)...)
 
-	_, verified := sign.Open(nil, signedMessage, toNaclPubKey(publicKey))
+	_, verified := sign.Open(nil, signedMessage, key)
 	return verified
 }
 
+// verifyEcdsaSignature return false for invalid keys & signatures encodings
 func verifyEcdsaSignature(publicKey, message, signature string) bool {
 	pubKeyBytes, err := hex.DecodeString(publicKey)
 	if err != nil {
-		panic(err)
+		return false
 	}
 	pubKey, err := btcec.ParsePubKey(pubKeyBytes)
 	if err != nil {
-		panic(err)
+		return false
 	}
 
 	sigBytes, err := hex.DecodeString(signature)
 	if err != nil {
-		panic(err)
+		return false
 	}
 	sign, err := ecdsa.ParseSignature(sigBytes)
 	if err != nil {
-		panic(err)
+		return false
 	}
 
 	messageHash := chainhash.DoubleHashB([]byte(message))
@@ -37,6 +37,9 @@ func Test_verifyEcdsaSignature(t *testing.T) {
 		want bool
 	}{
 		{"test", args{"024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "Hello, World!", "30440220046e86f0bff9639a893616e1db3abfa24cafa8818e7e47798c860d5982968ef502200241904a24128f6f73b8f5675368ff85992aa2b97bb40fe91ab361c96c62ca35"}, true},
+		{"invalid-hex", args{"024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "Hello, World!", "zz"}, false},
+		{"invalid-der", args{"024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "Hello, World!", "3000"}, false},
+		{"invalid-key", args{"zz", "Hello, World!", "3000"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
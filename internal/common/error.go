@@ -2,9 +2,63 @@ package common
 
 import "errors"
 
+// json-rpc error codes
+const (
+	CodeParseError        = -32700
+	CodeInvalidRequest    = -32600
+	CodeMethodNotFound    = -32601
+	CodeInvalidArgs       = -32602
+	CodeInternal          = -32603
+	CodeInvalidSignature  = -32001
+	CodeTimestampOutRange = -32002
+	CodeForbidden         = -32003
+	CodeQuotaExceeded     = -32004
+	CodeRateLimited       = -32005
+	CodeInvalidStamp      = -32006
+	CodeReplayed          = -32007
+)
+
+// Error is a json-rpc error with a defined code
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewError(code int, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// AsError return err as json-rpc error, internal error if err has no defined code
+func AsError(err error) *Error {
+	var result *Error
+	if errors.As(err, &result) {
+		return result
+	}
+	return NewError(CodeInternal, err.Error())
+}
+
 var (
-	InvalidArgsErr = errors.New("Invalid Args Error")
-	ListErr        = errors.New("List Error")
-	AddErr         = errors.New("Add Error")
-	RemoveErr      = errors.New("Remove Error")
+	InvalidArgsErr   = NewError(CodeInvalidArgs, "Invalid Args Error")
+	ListErr          = NewError(CodeInternal, "List Error")
+	AddErr           = NewError(CodeInternal, "Add Error")
+	RemoveErr        = NewError(CodeInternal, "Remove Error")
+	InternalErr      = NewError(CodeInternal, "Internal Error")
+	KeyTooLongErr    = NewError(CodeInvalidArgs, "Key Too Long Error")
+	EntryTooLargeErr = NewError(CodeInvalidArgs, "Entry Too Large Error")
+	KeyFullErr       = NewError(CodeQuotaExceeded, "Key Full Error")
+	TooManyWaitsErr  = NewError(CodeQuotaExceeded, "Too Many Waits Error")
+	SignatureErr     = NewError(CodeInvalidSignature, "Invalid Signature Error")
+	TimestampErr     = NewError(CodeTimestampOutRange, "Timestamp Out Of Range Error")
+	ForbiddenErr     = NewError(CodeForbidden, "Forbidden Error")
+	RateLimitErr     = NewError(CodeRateLimited, "Rate Limit Error")
+	StampErr         = NewError(CodeInvalidStamp, "Invalid Stamp Error")
+	StampReusedErr   = NewError(CodeInvalidStamp, "Stamp Reused Error")
+	ReplayErr        = NewError(CodeReplayed, "Signature Replayed Error")
 )
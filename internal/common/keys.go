@@ -35,6 +35,10 @@ func KeyHash(domain, key string) string {
 	return Hash(domain, "k", key)
 }
 
+func OrderHash(domain, key string) string {
+	return Hash(domain, "o", key)
+}
+
 func CountHash(domain, count string) string {
 	return Hash(domain, "c", count)
 }
@@ -0,0 +1,32 @@
+package common
+
+const (
+	EvictionPolicyReject  = "reject"
+	EvictionPolicyOldest  = "oldest"
+	EvictionPolicySoonest = "soonest"
+)
+
+// Limits applied by directories on Add.
+// Zero values are unlimited.
+type Limits struct {
+	MaxKeyLength   int
+	MaxEntrySize   int
+	MaxValues      int
+	EvictionPolicy string
+}
+
+// Check key & value sizes.
+func (p Limits) Check(key, value string) error {
+	if p.MaxKeyLength > 0 && len(key) > p.MaxKeyLength {
+		return KeyTooLongErr
+	}
+	if p.MaxEntrySize > 0 && len(value) > p.MaxEntrySize {
+		return EntryTooLargeErr
+	}
+	return nil
+}
+
+// Full return true if count values reach MaxValues.
+func (p Limits) Full(count int) bool {
+	return p.MaxValues > 0 && count >= p.MaxValues
+}
@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// TimeToLiveModes are the known TimeToLive modes
+var TimeToLiveModes = []string{"fast", "short", "normal", "default", "long"}
+
 // TimeToLive return duration from mode.
 func TimeToLive(mode string) time.Duration {
 	if len(mode) == 0 {
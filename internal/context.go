@@ -2,8 +2,10 @@ package internal
 
 import (
 	"context"
+	"sync/atomic"
 
 	soroban "soroban"
+	"soroban/internal/ratelimit"
 	"soroban/ipc"
 	"soroban/p2p"
 )
@@ -14,8 +16,23 @@ const (
 	SorobanDirectoryKey = ContextKey("soroban-directory")
 	SorobanP2PKey       = ContextKey("soroban-p2p")
 	SorobanIPCKey       = ContextKey("soroban-ipc")
+	SorobanConnKey      = ContextKey("soroban-conn")
+	SorobanRateLimitKey = ContextKey("soroban-ratelimit")
 )
 
+// AdminListener is the listener type of admin connections
+const AdminListener = "Admin"
+
+// ConnInfo hold per-connection state
+type ConnInfo struct {
+	// Listener is the listener type of the connection (IPv4, Tor)
+	Listener string
+	// Address is the remote IP of the connection, empty for Tor connections
+	Address string
+	// Waiters is the count of requests held by this connection
+	Waiters atomic.Int32
+}
+
 func DirectoryFromContext(ctx context.Context) soroban.Directory {
 	storage, _ := ctx.Value(SorobanDirectoryKey).(soroban.Directory)
 	return storage
@@ -30,3 +47,13 @@ func IPCFromContext(ctx context.Context) *ipc.IPCService {
 	result, _ := ctx.Value(SorobanIPCKey).(*ipc.IPCService)
 	return result
 }
+
+func ConnInfoFromContext(ctx context.Context) *ConnInfo {
+	result, _ := ctx.Value(SorobanConnKey).(*ConnInfo)
+	return result
+}
+
+func RateLimiterFromContext(ctx context.Context) *ratelimit.Limiter {
+	result, _ := ctx.Value(SorobanRateLimitKey).(*ratelimit.Limiter)
+	return result
+}
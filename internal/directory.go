@@ -1,25 +1,74 @@
 package internal
 
 import (
+	"context"
+
 	soroban "soroban"
+	"soroban/internal/common"
+	"soroban/internal/disk"
 	"soroban/internal/memory"
+	"soroban/internal/redis"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type DirectoryType string
 
 const (
 	DirectoryTypeMemory DirectoryType = "directory-memory"
+	DirectoryTypeRedis  DirectoryType = "directory-redis"
+	DirectoryTypeDisk   DirectoryType = "directory-disk"
 )
 
-func DefaultDirectory(domain string) soroban.Directory {
-	return NewDirectory(domain, DirectoryTypeMemory)
+// DefaultDirectory return a memory directory with options limits & snapshot
+func DefaultDirectory(ctx context.Context, options soroban.SorobanInfo) soroban.Directory {
+	return NewDirectory(ctx, options, DirectoryTypeMemory)
 }
 
-func NewDirectory(domain string, DirectoryType DirectoryType) soroban.Directory {
+func NewDirectory(ctx context.Context, options soroban.SorobanInfo, DirectoryType DirectoryType) soroban.Directory {
 	switch DirectoryType {
 	case DirectoryTypeMemory:
-		return memory.NewWithDomain(domain, memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+		return newMemoryDirectory(ctx, options)
+	case DirectoryTypeRedis:
+		directory, err := redis.NewWithDomain(options.Domain, options.RedisURL)
+		if err != nil {
+			log.WithError(err).Error("Failed to connect to redis")
+			return nil
+		}
+		directory.SetLimits(directoryLimits(options))
+		return directory
+	case DirectoryTypeDisk:
+		directory, err := disk.NewWithDomain(options.Domain, options.DataDir)
+		if err != nil {
+			log.WithError(err).Error("Failed to open disk directory")
+			return nil
+		}
+		directory.SetLimits(directoryLimits(options))
+		return directory
 	default:
-		return memory.NewWithDomain(domain, memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+		return newMemoryDirectory(ctx, options)
+	}
+}
+
+func newMemoryDirectory(ctx context.Context, options soroban.SorobanInfo) soroban.Directory {
+	directory := memory.NewWithDomain(options.Domain, memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	directory.SetLimits(directoryLimits(options))
+	if len(options.Snapshot) > 0 {
+		err := directory.EnableSnapshot(options.Snapshot, memory.DefaultSnapshotInterval)
+		if err != nil {
+			log.WithError(err).Error("Failed to restore directory snapshot")
+			return nil
+		}
+	}
+	go directory.StartSweeper(ctx, memory.DefaultSweepInterval)
+	return directory
+}
+
+func directoryLimits(options soroban.SorobanInfo) common.Limits {
+	return common.Limits{
+		MaxKeyLength:   options.MaxKeyLength,
+		MaxEntrySize:   options.MaxEntrySize,
+		MaxValues:      options.MaxValues,
+		EvictionPolicy: options.EvictionPolicy,
 	}
 }
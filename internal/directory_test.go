@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	soroban "soroban"
+	"soroban/internal/common"
+)
+
+func TestDefaultDirectory_Snapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	options := soroban.SorobanInfo{
+		Domain:   "soroban",
+		Snapshot: filepath.Join(t.TempDir(), "snapshot.json"),
+	}
+	directory := DefaultDirectory(ctx, options)
+	err := directory.Add("key", "value", time.Minute)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	err = directory.(io.Closer).Close()
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// values are restored from snapshot
+	directory = DefaultDirectory(ctx, options)
+	entries, err := directory.List("key")
+	if err != nil || !reflect.DeepEqual(entries, []string{"value"}) {
+		t.Errorf("List() = %v, %v", entries, err)
+	}
+}
+
+func TestDefaultDirectory_Limits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	directory := DefaultDirectory(ctx, soroban.SorobanInfo{
+		Domain:       "soroban",
+		MaxEntrySize: 4,
+	})
+	if err := directory.Add("key", "value", time.Minute); err != common.EntryTooLargeErr {
+		t.Errorf("Add() error = %v, want %v", err, common.EntryTooLargeErr)
+	}
+	if err := directory.Add("key", "val", time.Minute); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+}
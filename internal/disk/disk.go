@@ -0,0 +1,581 @@
+package disk
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	soroban "soroban"
+	"soroban/internal/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultFilename        string        = "directory.log"
+	DefaultSyncInterval    time.Duration = time.Second
+	DefaultCompactInterval time.Duration = 5 * time.Minute
+
+	// CorruptedSuffix is appended with a timestamp to corrupted log files moved aside on startup
+	CorruptedSuffix = ".corrupt"
+)
+
+const (
+	opAdd    = "add"
+	opRemove = "remove"
+)
+
+// record is a write-ahead log line
+type record struct {
+	Op       string `json:"op"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	ExpireOn int64  `json:"exp,omitempty"`
+	Sequence uint64 `json:"seq,omitempty"`
+}
+
+// entry is a value state
+type entry struct {
+	expireOn time.Time
+	sequence uint64
+}
+
+// Disk directory keep values in memory and append all changes to a log file.
+// Log file is replayed on startup and periodically compacted to live values.
+type Disk struct {
+	domain   string
+	filename string
+
+	limits common.Limits
+
+	mtx      sync.Mutex
+	file     *os.File
+	keys     map[string]map[string]*entry
+	sequence uint64
+	records  int
+	dirty    bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(dataDir string) (*Disk, error) {
+	return NewWithDomain("soroban", dataDir)
+}
+
+func NewWithDomain(domain, dataDir string) (*Disk, error) {
+	if len(dataDir) == 0 {
+		return nil, errors.New("invalid data directory")
+	}
+	err := os.MkdirAll(dataDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Disk{
+		domain:   domain,
+		filename: filepath.Join(dataDir, DefaultFilename),
+		keys:     make(map[string]map[string]*entry),
+		done:     make(chan struct{}),
+	}
+
+	corrupted, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	// keep corrupted log for inspection, compaction drops corrupted records
+	if corrupted {
+		backup := fmt.Sprintf("%s%s.%d", d.filename, CorruptedSuffix, time.Now().Unix())
+		err = os.Rename(d.filename, backup)
+		if err != nil {
+			return nil, err
+		}
+		log.WithField("Filename", backup).Error("Corrupted directory log moved")
+	}
+
+	// start with a clean log containing only live values
+	err = d.compact()
+	if err != nil {
+		return nil, err
+	}
+
+	d.wg.Add(1)
+	go d.maintenance()
+
+	return d, nil
+}
+
+// SetLimits applied on Add, must be called before use.
+func (d *Disk) SetLimits(limits common.Limits) {
+	d.limits = limits
+}
+
+// Close sync and close log file
+func (d *Disk) Close() error {
+	close(d.done)
+	d.wg.Wait()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	err := d.file.Sync()
+	if err != nil {
+		return err
+	}
+	return d.file.Close()
+}
+
+// Status returs internal informations
+func (d *Disk) Status() (soroban.StatusInfo, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	values := 0
+	for _, entries := range d.keys {
+		values += len(entries)
+	}
+
+	size := int64(0)
+	if info, err := d.file.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return soroban.StatusInfo{
+		Keyspace: soroban.NameValue{
+			"keys":   strconv.Itoa(len(d.keys)),
+			"values": strconv.Itoa(values),
+		},
+		Persistence: soroban.NameValue{
+			"log_records": strconv.Itoa(d.records),
+			"log_size":    strconv.FormatInt(size, 10),
+		},
+	}, nil
+}
+
+// TimeToLive return duration from mode.
+func (d *Disk) TimeToLive(mode string) time.Duration {
+	return common.TimeToLive(mode)
+}
+
+// List return all known values for this key.
+func (d *Disk) List(key string) ([]string, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if len(key) == 0 {
+		return nil, common.InvalidArgsErr
+	}
+
+	key = common.KeyHash(d.domain, key)
+
+	now := now()
+	entries := d.keys[key]
+	values := make([]string, 0, len(entries))
+	for value, entry := range entries {
+		if entry.expireOn.Before(now) {
+			continue
+		}
+		values = append(values, value)
+	}
+
+	// insertion order
+	sort.Slice(values, func(i, j int) bool {
+		return entries[values[i]].sequence < entries[values[j]].sequence
+	})
+
+	return values, nil
+}
+
+// Entries return all known values for this key with metadata, in insertion order.
+func (d *Disk) Entries(key string) ([]soroban.Entry, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if len(key) == 0 {
+		return nil, common.InvalidArgsErr
+	}
+
+	key = common.KeyHash(d.domain, key)
+
+	now := now()
+	result := make([]soroban.Entry, 0, len(d.keys[key]))
+	for value, entry := range d.keys[key] {
+		if entry.expireOn.Before(now) {
+			continue
+		}
+		result = append(result, soroban.Entry{
+			Value:    value,
+			Sequence: entry.sequence,
+			ExpireOn: entry.expireOn,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Sequence < result[j].Sequence
+	})
+
+	return result, nil
+}
+
+// Add value in key.
+// TimeToLive must be greter or equals to 1 second.
+// Multiple values can be store with the same key.
+// Each value expires on its own.
+func (d *Disk) Add(key, value string, TTL time.Duration) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if len(key) == 0 || len(value) == 0 || TTL < time.Second {
+		return common.InvalidArgsErr
+	}
+
+	if err := d.limits.Check(key, value); err != nil {
+		return err
+	}
+
+	key = common.KeyHash(d.domain, key)
+	now := now()
+	expireOn := now.Add(TTL)
+
+	purgeKey(d.keys, key, now)
+	entries := d.keys[key]
+
+	sequence := d.sequence + 1
+	if current, ok := entries[value]; ok {
+		sequence = current.sequence
+	} else if d.limits.Full(len(entries)) {
+		var victim string
+		switch d.limits.EvictionPolicy {
+		case common.EvictionPolicyOldest:
+			victim = oldest(entries)
+		case common.EvictionPolicySoonest:
+			victim = soonest(entries)
+		default:
+			return common.KeyFullErr
+		}
+
+		r := record{
+			Op:    opRemove,
+			Key:   key,
+			Value: victim,
+		}
+		if err := d.append(r); err != nil {
+			log.WithError(err).Error("Failed to append to directory log")
+			return common.AddErr
+		}
+		d.apply(r)
+	}
+
+	r := record{
+		Op:       opAdd,
+		Key:      key,
+		Value:    value,
+		ExpireOn: expireOn.UnixMilli(),
+		Sequence: sequence,
+	}
+	err := d.append(r)
+	if err != nil {
+		log.WithError(err).Error("Failed to append to directory log")
+		return common.AddErr
+	}
+
+	d.apply(r)
+	return nil
+}
+
+// Remove value from key.
+func (d *Disk) Remove(key, value string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if len(key) == 0 {
+		return common.InvalidArgsErr
+	}
+
+	key = common.KeyHash(d.domain, key)
+	if _, ok := d.keys[key][value]; !ok {
+		return nil
+	}
+
+	r := record{
+		Op:    opRemove,
+		Key:   key,
+		Value: value,
+	}
+	err := d.append(r)
+	if err != nil {
+		log.WithError(err).Error("Failed to append to directory log")
+		return common.RemoveErr
+	}
+
+	d.apply(r)
+	return nil
+}
+
+// Pop atomically remove and return up to count values from key, in insertion order.
+func (d *Disk) Pop(key string, count int) ([]string, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if len(key) == 0 || count < 1 {
+		return nil, common.InvalidArgsErr
+	}
+
+	key = common.KeyHash(d.domain, key)
+	purgeKey(d.keys, key, now())
+
+	entries := d.keys[key]
+	values := make([]string, 0, len(entries))
+	for value := range entries {
+		values = append(values, value)
+	}
+	// insertion order
+	sort.Slice(values, func(i, j int) bool {
+		return entries[values[i]].sequence < entries[values[j]].sequence
+	})
+	if count < len(values) {
+		values = values[:count]
+	}
+
+	for _, value := range values {
+		r := record{
+			Op:    opRemove,
+			Key:   key,
+			Value: value,
+		}
+		err := d.append(r)
+		if err != nil {
+			log.WithError(err).Error("Failed to append to directory log")
+			return nil, common.RemoveErr
+		}
+		d.apply(r)
+	}
+
+	return values, nil
+}
+
+// apply record to in-memory state, must be called with lock held.
+func (d *Disk) apply(r record) {
+	switch r.Op {
+	case opAdd:
+		entries, ok := d.keys[r.Key]
+		if !ok {
+			entries = make(map[string]*entry)
+			d.keys[r.Key] = entries
+		}
+		entries[r.Value] = &entry{
+			expireOn: time.UnixMilli(r.ExpireOn).UTC(),
+			sequence: r.Sequence,
+		}
+		if r.Sequence > d.sequence {
+			d.sequence = r.Sequence
+		}
+
+	case opRemove:
+		delete(d.keys[r.Key], r.Value)
+	}
+
+	// keep non-expired values
+	purgeKey(d.keys, r.Key, now())
+}
+
+// append record to log file, must be called with lock held.
+func (d *Disk) append(r record) error {
+	data, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	_, err = d.file.Write(data)
+	if err != nil {
+		return err
+	}
+	d.records++
+	d.dirty = true
+	return nil
+}
+
+// load replay log file, corrupted records are skipped.
+// Return true if a complete record was corrupted, a truncated last record is expected after a crash.
+func (d *Disk) load() (bool, error) {
+	file, err := os.Open(d.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	count := 0
+	corrupted := 0
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Warning("Ignoring truncated directory log record")
+			}
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			log.WithError(err).Warning("Ignoring corrupted directory log record")
+			corrupted++
+			continue
+		}
+
+		d.apply(r)
+		count++
+	}
+
+	log.WithField("Records", count).WithField("Corrupted", corrupted).Info("Directory log loaded")
+	return corrupted > 0, nil
+}
+
+// compact rewrite log file with live values only.
+func (d *Disk) compact() error {
+	now := now()
+	tmpFilename := d.filename + ".tmp"
+
+	tmp, err := os.OpenFile(tmpFilename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	records := 0
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for key := range d.keys {
+		purgeKey(d.keys, key, now)
+		for value, entry := range d.keys[key] {
+			err = encoder.Encode(&record{
+				Op:       opAdd,
+				Key:      key,
+				Value:    value,
+				ExpireOn: entry.expireOn.UnixMilli(),
+				Sequence: entry.sequence,
+			})
+			if err != nil {
+				tmp.Close()
+				return err
+			}
+			records++
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFilename, d.filename); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(d.filename, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if d.file != nil {
+		d.file.Close()
+	}
+	d.file = file
+	d.records = records
+	d.dirty = false
+
+	return nil
+}
+
+// maintenance periodically sync and compact log file
+func (d *Disk) maintenance() {
+	defer d.wg.Done()
+
+	syncTicker := time.NewTicker(DefaultSyncInterval)
+	defer syncTicker.Stop()
+	compactTicker := time.NewTicker(DefaultCompactInterval)
+	defer compactTicker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+
+		case <-syncTicker.C:
+			d.mtx.Lock()
+			if d.dirty {
+				if err := d.file.Sync(); err != nil {
+					log.WithError(err).Error("Failed to sync directory log")
+				}
+				d.dirty = false
+			}
+			d.mtx.Unlock()
+
+		case <-compactTicker.C:
+			d.mtx.Lock()
+			if err := d.compact(); err != nil {
+				log.WithError(err).Error("Failed to compact directory log")
+			}
+			d.mtx.Unlock()
+		}
+	}
+}
+
+func purgeKey(keys map[string]map[string]*entry, key string, limit time.Time) {
+	entries := keys[key]
+	for value, entry := range entries {
+		if entry.expireOn.Before(limit) {
+			delete(entries, value)
+		}
+	}
+	if len(entries) == 0 {
+		delete(keys, key)
+	}
+}
+
+// oldest return first inserted value
+func oldest(entries map[string]*entry) string {
+	var result string
+	var sequence uint64
+	for value, entry := range entries {
+		if len(result) == 0 || entry.sequence < sequence {
+			result, sequence = value, entry.sequence
+		}
+	}
+	return result
+}
+
+// soonest return the soonest to expire value
+func soonest(entries map[string]*entry) string {
+	var result string
+	var expireOn time.Time
+	for value, entry := range entries {
+		if len(result) == 0 || entry.expireOn.Before(expireOn) {
+			result, expireOn = value, entry.expireOn
+		}
+	}
+	return result
+}
+
+func now() time.Time {
+	return time.Now().Truncate(time.Millisecond).UTC()
+}
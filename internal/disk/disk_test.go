@@ -0,0 +1,128 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"soroban/internal/common"
+)
+
+func TestDisk_Reload(t *testing.T) {
+	dataDir := t.TempDir()
+
+	directory, err := New(dataDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, value := range []string{"a", "b", "c"} {
+		if err := directory.Add("key", value, time.Minute); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := directory.Remove("key", "b"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := directory.Add("expired", "value", time.Second); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := directory.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	directory, err = New(dataDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer directory.Close()
+
+	got, _ := directory.List("key")
+	sort.Strings(got)
+	if want := []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if got, _ := directory.List("expired"); len(got) != 0 {
+		t.Errorf("List() = %v, want empty", got)
+	}
+
+	// compacted log only contains live values
+	if directory.records != 2 {
+		t.Errorf("records = %d, want 2", directory.records)
+	}
+}
+
+func TestDisk_CorruptedTail(t *testing.T) {
+	dataDir := t.TempDir()
+
+	directory, err := New(dataDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := directory.Add("key", "a", time.Minute); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	directory.Close()
+
+	file, err := os.OpenFile(directory.filename, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"op":"add","key":`)
+	file.Close()
+
+	directory, err = New(dataDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer directory.Close()
+
+	if got, _ := directory.List("key"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("List() = %v, want [a]", got)
+	}
+}
+
+func TestDisk_CorruptedRecord(t *testing.T) {
+	dataDir := t.TempDir()
+
+	directory, err := New(dataDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := directory.Add("key", "a", time.Minute); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	directory.Close()
+
+	file, err := os.OpenFile(directory.filename, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expireOn := time.Now().Add(time.Minute).UnixMilli()
+	file.WriteString("{\"op\":\"add\",\"key\":\n")
+	file.WriteString(fmt.Sprintf("{\"op\":\"add\",\"key\":%q,\"value\":\"b\",\"exp\":%d,\"seq\":10}\n", common.KeyHash("soroban", "key"), expireOn))
+	file.Close()
+
+	directory, err = New(dataDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer directory.Close()
+
+	// records after the corrupted one are kept
+	got, _ := directory.List("key")
+	sort.Strings(got)
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	// original log is moved aside
+	backups, _ := filepath.Glob(directory.filename + CorruptedSuffix + ".*")
+	if len(backups) != 1 {
+		t.Errorf("corrupted log backups = %v", backups)
+	}
+}
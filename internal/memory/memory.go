@@ -1,25 +1,54 @@
 package memory
 
 import (
+	"hash/fnv"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	soroban "soroban"
 	"soroban/internal/common"
-
-	"github.com/shaj13/libcache"
-	_ "github.com/shaj13/libcache/arc"
 )
 
 const (
 	DefaultCacheTTL      time.Duration = 15 * time.Minute
 	DefaultCacheCapacity int           = 100000
+	DefaultShardCount    int           = 32
+
+	// approximate memory overhead of a key or a value entry
+	keyOverhead   = 128
+	valueOverhead = 64
 )
 
+type counters struct {
+	add           atomic.Uint64
+	list          atomic.Uint64
+	remove        atomic.Uint64
+	evictedKeys   atomic.Uint64
+	evictedValues atomic.Uint64
+	expiredKeys   atomic.Uint64
+	expiredValues atomic.Uint64
+	rejected      atomic.Uint64
+	dropped       atomic.Uint64
+	sweeps        atomic.Uint64
+	liveKeys      atomic.Uint64
+	liveValues    atomic.Uint64
+}
+
+// Memory directory shard the key space over independent caches & locks.
+// List does not update cache "recent-ness", only writes do.
 type Memory struct {
-	domain string
-	cache  libcache.Cache
-	mtx    sync.Mutex
+	domain   string
+	capacity int
+	limits   common.Limits
+	shards   []*shard
+	sequence atomic.Uint64
+	counters counters
+
+	snapshot string
+	done     chan struct{}
+	wg       sync.WaitGroup
 }
 
 func New(count int, ttl time.Duration) *Memory {
@@ -27,18 +56,93 @@ func New(count int, ttl time.Duration) *Memory {
 }
 
 func NewWithDomain(domain string, count int, ttl time.Duration) *Memory {
-	cache := libcache.ARC.NewUnsafe(count)
-	cache.SetTTL(ttl)
+	return NewSharded(domain, count, ttl, DefaultShardCount)
+}
+
+// NewSharded split count capacity over shardCount shards.
+func NewSharded(domain string, count int, ttl time.Duration, shardCount int) *Memory {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+	if shardCount > count {
+		shardCount = count
+	}
 
-	return &Memory{
-		domain: domain,
-		cache:  cache,
+	m := &Memory{
+		domain:   domain,
+		capacity: count,
+		shards:   make([]*shard, shardCount),
+	}
+	for i := range m.shards {
+		m.shards[i] = newShard(count/shardCount, ttl, &m.counters)
 	}
+	return m
+}
+
+// SetLimits applied on Add, must be called before use.
+func (m *Memory) SetLimits(limits common.Limits) {
+	m.limits = limits
+}
+
+// getShard return key shard, key must be hashed.
+func (m *Memory) getShard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
 }
 
 // Status returs internal informations
 func (m *Memory) Status() (soroban.StatusInfo, error) {
-	return soroban.StatusInfo{}, nil
+	now := now()
+	keys := 0
+	values := 0
+	bytes := 0
+	for _, shard := range m.shards {
+		shard.mtx.RLock()
+		for key, list := range shard.lists {
+			count := 0
+			for _, value := range list.values {
+				if value.expireOn.Before(now) {
+					continue
+				}
+				count++
+				bytes += valueOverhead + len(value.value)
+			}
+			if count == 0 {
+				continue
+			}
+			keys++
+			values += count
+			bytes += keyOverhead + len(key)
+		}
+		shard.mtx.RUnlock()
+	}
+
+	return soroban.StatusInfo{
+		Keyspace: soroban.NameValue{
+			"keys":   strconv.Itoa(keys),
+			"values": strconv.Itoa(values),
+		},
+		Memory: soroban.NameValue{
+			"used_bytes_approx": strconv.Itoa(bytes),
+			"cache_capacity":    strconv.Itoa(m.capacity),
+			"shards":            strconv.Itoa(len(m.shards)),
+		},
+		Stats: soroban.NameValue{
+			"add_count":      formatCounter(&m.counters.add),
+			"list_count":     formatCounter(&m.counters.list),
+			"remove_count":   formatCounter(&m.counters.remove),
+			"evicted_keys":   formatCounter(&m.counters.evictedKeys),
+			"evicted_values": formatCounter(&m.counters.evictedValues),
+			"expired_keys":   formatCounter(&m.counters.expiredKeys),
+			"expired_values": formatCounter(&m.counters.expiredValues),
+			"rejected_adds":  formatCounter(&m.counters.rejected),
+			"dropped_values": formatCounter(&m.counters.dropped),
+			"sweeps":         formatCounter(&m.counters.sweeps),
+			"live_keys":      formatCounter(&m.counters.liveKeys),
+			"live_values":    formatCounter(&m.counters.liveValues),
+		},
+	}, nil
 }
 
 // TimeToLive return duration from mode.
@@ -47,24 +151,65 @@ func (m *Memory) TimeToLive(mode string) time.Duration {
 }
 
 // List return all known values for this key.
+// Expired values are skipped, they are purged by writers.
 func (m *Memory) List(key string) ([]string, error) {
-	m.mtx.Lock()
-	defer m.mtx.Unlock()
-
 	if len(key) == 0 {
 		return nil, common.InvalidArgsErr
 	}
 
+	m.counters.list.Add(1)
 	key = common.KeyHash(m.domain, key)
 
-	list := getKeyList(m.cache, key)
+	shard := m.getShard(key)
+	shard.mtx.RLock()
+	defer shard.mtx.RUnlock()
+
+	now := now()
+	list := shard.lists[key]
+	if list == nil {
+		return make([]string, 0), nil
+	}
 	result := make([]string, 0, len(list.values))
 	for _, entry := range list.values {
+		if entry.expireOn.Before(now) {
+			continue
+		}
 		result = append(result, entry.value)
 	}
 
-	// keep non-expired values
-	purgeKeyList(list, now())
+	return result, nil
+}
+
+// Entries return all known values for this key with metadata.
+// Values are stored in insertion order.
+func (m *Memory) Entries(key string) ([]soroban.Entry, error) {
+	if len(key) == 0 {
+		return nil, common.InvalidArgsErr
+	}
+
+	m.counters.list.Add(1)
+	key = common.KeyHash(m.domain, key)
+
+	shard := m.getShard(key)
+	shard.mtx.RLock()
+	defer shard.mtx.RUnlock()
+
+	now := now()
+	list := shard.lists[key]
+	if list == nil {
+		return make([]soroban.Entry, 0), nil
+	}
+	result := make([]soroban.Entry, 0, len(list.values))
+	for _, entry := range list.values {
+		if entry.expireOn.Before(now) {
+			continue
+		}
+		result = append(result, soroban.Entry{
+			Value:    entry.value,
+			Sequence: entry.sequence,
+			ExpireOn: entry.expireOn,
+		})
+	}
 
 	return result, nil
 }
@@ -72,92 +217,168 @@ func (m *Memory) List(key string) ([]string, error) {
 // Add value in key.
 // TimeToLive must be greter or equals to 1 second.
 // Multiple values can be store with the same key.
-// TTL is the same for all values.
+// Each value expires on its own, key lifetime is the latest value expiry.
 func (m *Memory) Add(key, value string, TTL time.Duration) error {
-	m.mtx.Lock()
-	defer m.mtx.Unlock()
-
 	if len(key) == 0 || len(value) == 0 || TTL < time.Second {
 		return common.InvalidArgsErr
 	}
 
+	if err := m.limits.Check(key, value); err != nil {
+		m.counters.rejected.Add(1)
+		return err
+	}
+
+	m.counters.add.Add(1)
 	key = common.KeyHash(m.domain, key)
 
-	list := getKeyList(m.cache, key)
-	list.TTL = TTL
+	shard := m.getShard(key)
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
+
+	list := shard.getKeyList(key)
 
 	now := now()
 	expireOn := now.Add(TTL)
 
+	// keep non-expired values
+	m.counters.expiredValues.Add(uint64(purgeKeyList(list, now)))
+
 	exists, pos := contains(list.values, value)
 	if !exists {
+		if m.limits.Full(len(list.values)) {
+			switch m.limits.EvictionPolicy {
+			case common.EvictionPolicyOldest:
+				list.values = remove(list.values, 0)
+			case common.EvictionPolicySoonest:
+				list.values = remove(list.values, soonest(list.values))
+			default:
+				m.counters.rejected.Add(1)
+				return common.KeyFullErr
+			}
+			m.counters.dropped.Add(1)
+		}
+
 		// add new value
 		list.values = append(list.values, &valueEntry{
 			value:    value,
 			expireOn: expireOn,
+			sequence: m.sequence.Add(1),
 		})
 	} else {
 		// update value expireOn
 		list.values[pos].expireOn = expireOn
 	}
 
-	// keep non-expired values
-	purgeKeyList(list, now)
-
-	m.cache.StoreWithTTL(key, list, list.TTL)
+	shard.store(key, list, now)
 
 	return nil
 }
 
 // Remove value from key.
 func (m *Memory) Remove(key, value string) error {
-	m.mtx.Lock()
-	defer m.mtx.Unlock()
-
 	if len(key) == 0 {
 		return common.InvalidArgsErr
 	}
 
+	m.counters.remove.Add(1)
 	key = common.KeyHash(m.domain, key)
 
-	list := getKeyList(m.cache, key)
-	if _, pos := contains(list.values, value); pos != -1 {
+	shard := m.getShard(key)
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
+
+	list := shard.getKeyList(key)
+	_, pos := contains(list.values, value)
+	if pos != -1 {
 		list.values = remove(list.values, pos)
-		m.cache.StoreWithTTL(key, list, list.TTL)
 	}
 
 	// keep non-expired values
-	purgeKeyList(list, now())
+	now := now()
+	m.counters.expiredValues.Add(uint64(purgeKeyList(list, now)))
 
 	if len(list.values) == 0 {
-		m.cache.Delete(key)
+		shard.delete(key)
+		return nil
+	}
+	if pos != -1 {
+		// key lifetime may be shorter without removed value
+		shard.store(key, list, now)
 	}
 	return nil
 }
 
+// Pop atomically remove and return up to count values from key, in insertion order.
+func (m *Memory) Pop(key string, count int) ([]string, error) {
+	if len(key) == 0 || count < 1 {
+		return nil, common.InvalidArgsErr
+	}
+
+	m.counters.remove.Add(1)
+	key = common.KeyHash(m.domain, key)
+
+	shard := m.getShard(key)
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
+
+	list := shard.getKeyList(key)
+
+	// keep non-expired values
+	now := now()
+	m.counters.expiredValues.Add(uint64(purgeKeyList(list, now)))
+
+	if count > len(list.values) {
+		count = len(list.values)
+	}
+	result := make([]string, 0, count)
+	for _, entry := range list.values[:count] {
+		result = append(result, entry.value)
+	}
+	list.values = append(list.values[:0], list.values[count:]...)
+
+	if len(list.values) == 0 {
+		shard.delete(key)
+		return result, nil
+	}
+	if count > 0 {
+		// key lifetime may be shorter without removed values
+		shard.store(key, list, now)
+	}
+	return result, nil
+}
+
 type valueEntry struct {
 	expireOn time.Time
 	value    string
+	sequence uint64
 }
 
 type keyList struct {
-	TTL    time.Duration
 	values []*valueEntry
 }
 
-func getKeyList(cache libcache.Cache, key string) *keyList {
-	if cache.Contains(key) {
-		if entry, ok := cache.Load(key); ok {
-			switch result := entry.(type) {
-			case *keyList:
-				return result
-			}
+// TTL return key lifetime from the latest-expiring value
+func (l *keyList) TTL(now time.Time) time.Duration {
+	var expireOn time.Time
+	for _, value := range l.values {
+		if value.expireOn.After(expireOn) {
+			expireOn = value.expireOn
 		}
 	}
+	return expireOn.Sub(now)
+}
+
+// getKeyList return key list from index, must be called with lock held.
+func (s *shard) getKeyList(key string) *keyList {
+	if list, ok := s.lists[key]; ok {
+		return list
+	}
 	return &keyList{}
 }
 
-func purgeKeyList(list *keyList, limit time.Time) {
+// purgeKeyList remove expired values and return the removed count
+func purgeKeyList(list *keyList, limit time.Time) int {
+	count := len(list.values)
 	values := list.values[:0]
 	for _, value := range list.values {
 		if value.expireOn.Before(limit) {
@@ -166,6 +387,11 @@ func purgeKeyList(list *keyList, limit time.Time) {
 		values = append(values, value)
 	}
 	list.values = values[:]
+	return count - len(list.values)
+}
+
+func formatCounter(counter *atomic.Uint64) string {
+	return strconv.FormatUint(counter.Load(), 10)
 }
 
 func contains(slice []*valueEntry, value string) (bool, int) {
@@ -177,10 +403,21 @@ func contains(slice []*valueEntry, value string) (bool, int) {
 	return false, -1
 }
 
+// soonest return position of the soonest to expire value
+func soonest(slice []*valueEntry) int {
+	result := 0
+	for i, entry := range slice {
+		if entry.expireOn.Before(slice[result].expireOn) {
+			result = i
+		}
+	}
+	return result
+}
+
 func remove(slice []*valueEntry, s int) []*valueEntry {
 	return append(slice[:s], slice[s+1:]...)
 }
 
-func now() time.Time {
+var now = func() time.Time {
 	return time.Now().Truncate(time.Millisecond).UTC()
 }
@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"soroban/internal/common"
+)
+
+func TestMemory_Status(t *testing.T) {
+	m := NewSharded("soroban", 2, DefaultCacheTTL, 1)
+
+	m.Add("a", "1", time.Minute)
+	m.Add("a", "2", time.Minute)
+	m.Add("b", "1", time.Minute)
+	m.List("a")
+	m.Remove("a", "2")
+	// capacity is 2, key c evicts one key
+	m.Add("c", "1", time.Minute)
+
+	status, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"keys", status.Keyspace["keys"], "2"},
+		{"cache_capacity", status.Memory["cache_capacity"], "2"},
+		{"add_count", status.Stats["add_count"], "4"},
+		{"list_count", status.Stats["list_count"], "1"},
+		{"remove_count", status.Stats["remove_count"], "1"},
+		{"evicted_keys", status.Stats["evicted_keys"], "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("Status() %s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemory_MixedModes(t *testing.T) {
+	fast := common.TimeToLive("fast")
+	long := common.TimeToLive("long")
+
+	tests := []struct {
+		name   string
+		adds   []string
+		remove string
+		want   time.Duration
+	}{
+		{"fast-after-long", []string{"long", "fast"}, "", long},
+		{"long-after-fast", []string{"fast", "long"}, "", long},
+		{"fast-only", []string{"fast"}, "", fast},
+		{"remove-long", []string{"long", "fast"}, "long", fast},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(DefaultCacheCapacity, DefaultCacheTTL)
+			for _, mode := range tt.adds {
+				m.Add("key", mode, m.TimeToLive(mode))
+			}
+			if len(tt.remove) > 0 {
+				m.Remove("key", tt.remove)
+			}
+
+			key := common.KeyHash(m.domain, "key")
+			expiry, ok := m.getShard(key).cache.Expiry(key)
+			if !ok {
+				t.Fatal("key not found in cache")
+			}
+			got := time.Until(expiry)
+			if got > tt.want || got < tt.want-time.Second {
+				t.Errorf("key TTL = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemory_PerValueExpiry(t *testing.T) {
+	saved := now
+	defer func() { now = saved }()
+
+	m := New(DefaultCacheCapacity, DefaultCacheTTL)
+	m.Add("key", "long", m.TimeToLive("long"))
+	m.Add("key", "fast", m.TimeToLive("fast"))
+	m.Add("key", "short", m.TimeToLive("short"))
+
+	start := now()
+	tests := []struct {
+		name  string
+		after time.Duration
+		want  []string
+	}{
+		{"all", 0, []string{"long", "fast", "short"}},
+		{"fast-expired", m.TimeToLive("fast") + time.Second, []string{"long", "short"}},
+		{"short-expired", m.TimeToLive("short") + time.Second, []string{"long"}},
+		{"long-expired", m.TimeToLive("long") + time.Second, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = func() time.Time {
+				return start.Add(tt.after)
+			}
+			got, err := m.List("key")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func benchmarkParallel(b *testing.B, shardCount int, writeRatio int) {
+	m := NewSharded("soroban", DefaultCacheCapacity, DefaultCacheTTL, shardCount)
+
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key.%d", i)
+		m.Add(keys[i], "value", time.Minute)
+	}
+
+	var counter atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		seed := counter.Add(1)
+		i := int(seed * 7919)
+		for pb.Next() {
+			i++
+			key := keys[i%len(keys)]
+			if i%100 < writeRatio {
+				m.Add(key, strconv.Itoa(i%16), time.Minute)
+			} else {
+				m.List(key)
+			}
+		}
+	})
+}
+
+func BenchmarkMemory_Parallel(b *testing.B) {
+	for _, writeRatio := range []int{10, 50} {
+		for _, shardCount := range []int{1, DefaultShardCount} {
+			name := fmt.Sprintf("writes=%d%%/shards=%d", writeRatio, shardCount)
+			b.Run(name, func(b *testing.B) {
+				benchmarkParallel(b, shardCount, writeRatio)
+			})
+		}
+	}
+}
+
+func TestMemory_Sweep(t *testing.T) {
+	saved := now
+	defer func() { now = saved }()
+
+	m := New(DefaultCacheCapacity, DefaultCacheTTL)
+	m.Add("a", "fast", m.TimeToLive("fast"))
+	m.Add("a", "long", m.TimeToLive("long"))
+	m.Add("b", "fast", m.TimeToLive("fast"))
+
+	start := now()
+	now = func() time.Time {
+		return start.Add(m.TimeToLive("fast") + time.Second)
+	}
+	m.Sweep()
+
+	tests := []struct {
+		name string
+		got  uint64
+		want uint64
+	}{
+		{"expired_keys", m.counters.expiredKeys.Load(), 1},
+		{"expired_values", m.counters.expiredValues.Load(), 2},
+		{"live_keys", m.counters.liveKeys.Load(), 1},
+		{"live_values", m.counters.liveValues.Load(), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+
+	key := common.KeyHash(m.domain, "b")
+	if _, ok := m.getShard(key).lists[key]; ok {
+		t.Error("empty key not removed from index")
+	}
+}
+
+func TestMemory_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  common.Limits
+		key     string
+		value   string
+		wantErr error
+		want    []string
+	}{
+		{"key-too-long", common.Limits{MaxKeyLength: 3}, "long-key", "d", common.KeyTooLongErr, nil},
+		{"entry-too-large", common.Limits{MaxEntrySize: 1}, "key", "dd", common.EntryTooLargeErr, []string{"a", "b", "c"}},
+		{"reject", common.Limits{MaxValues: 3, EvictionPolicy: common.EvictionPolicyReject}, "key", "d", common.KeyFullErr, []string{"a", "b", "c"}},
+		{"refresh", common.Limits{MaxValues: 3, EvictionPolicy: common.EvictionPolicyReject}, "key", "a", nil, []string{"a", "b", "c"}},
+		{"oldest", common.Limits{MaxValues: 3, EvictionPolicy: common.EvictionPolicyOldest}, "key", "d", nil, []string{"b", "c", "d"}},
+		{"soonest", common.Limits{MaxValues: 3, EvictionPolicy: common.EvictionPolicySoonest}, "key", "d", nil, []string{"a", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(DefaultCacheCapacity, DefaultCacheTTL)
+			m.Add("key", "a", m.TimeToLive("long"))
+			m.Add("key", "b", m.TimeToLive("fast"))
+			m.Add("key", "c", m.TimeToLive("normal"))
+			m.SetLimits(tt.limits)
+
+			if err := m.Add(tt.key, tt.value, m.TimeToLive("normal")); err != tt.wantErr {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			got, _ := m.List("key")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemory_Pop(t *testing.T) {
+	m := New(DefaultCacheCapacity, DefaultCacheTTL)
+	for _, value := range []string{"a", "b", "c"} {
+		m.Add("key", value, m.TimeToLive("normal"))
+	}
+
+	tests := []struct {
+		name  string
+		count int
+		want  []string
+	}{
+		{"first", 2, []string{"a", "b"}},
+		{"last", 2, []string{"c"}},
+		{"empty", 1, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Pop("key", tt.count)
+			if err != nil {
+				t.Fatalf("Pop() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pop() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Pop("key", 0); err != common.InvalidArgsErr {
+		t.Errorf("Pop() error = %v, want %v", err, common.InvalidArgsErr)
+	}
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shaj13/libcache"
+	_ "github.com/shaj13/libcache/arc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// shard own a part of the key space.
+// lists index is used by readers, cache is only used by writers
+// for capacity eviction & key expiration.
+type shard struct {
+	mtx      sync.RWMutex
+	lists    map[string]*keyList
+	cache    libcache.Cache
+	events   chan libcache.Event
+	counters *counters
+}
+
+func newShard(count int, ttl time.Duration, counters *counters) *shard {
+	cache := libcache.ARC.NewUnsafe(count)
+	cache.SetTTL(ttl)
+
+	// cache removals are used for index cleanup, eviction & expiration accounting
+	events := make(chan libcache.Event, 1024)
+	cache.Notify(events, libcache.Remove)
+
+	return &shard{
+		lists:    make(map[string]*keyList),
+		cache:    cache,
+		events:   events,
+		counters: counters,
+	}
+}
+
+// store list in cache & index, must be called with lock held.
+func (s *shard) store(key string, list *keyList, now time.Time) {
+	s.cache.StoreWithTTL(key, list, list.TTL(now))
+	s.accountEvents()
+	// index after events, cache may have expired the previous entry
+	s.lists[key] = list
+}
+
+// delete list from cache & index, must be called with lock held.
+func (s *shard) delete(key string) {
+	delete(s.lists, key)
+	s.cache.Delete(key)
+	s.discardEvents()
+}
+
+// accountEvents remove evicted or expired keys from index.
+// must be called with lock held.
+func (s *shard) accountEvents() {
+	now := time.Now()
+	count := 0
+	for {
+		select {
+		case event := <-s.events:
+			count++
+			list, ok := event.Value.(*keyList)
+			if !ok {
+				continue
+			}
+			key, _ := event.Key.(string)
+			if current, ok := s.lists[key]; ok && current == list {
+				delete(s.lists, key)
+			}
+
+			if !event.Expiry.IsZero() && !event.Expiry.After(now) {
+				s.counters.expiredKeys.Add(1)
+				s.counters.expiredValues.Add(uint64(len(list.values)))
+				continue
+			}
+			// only count values lost before their expiry
+			evicted := 0
+			for _, value := range list.values {
+				if value.expireOn.After(now) {
+					evicted++
+				}
+			}
+			s.counters.evictedKeys.Add(1)
+			s.counters.evictedValues.Add(uint64(evicted))
+			if evicted > 0 {
+				log.WithField("Values", evicted).Debug("Directory key evicted before expiry")
+			}
+
+		default:
+			if count >= cap(s.events) {
+				// some events may be lost
+				s.reconcile()
+			}
+			return
+		}
+	}
+}
+
+// discardEvents ignore pending cache removals after an explicit delete.
+// must be called with lock held.
+func (s *shard) discardEvents() {
+	for {
+		select {
+		case <-s.events:
+		default:
+			return
+		}
+	}
+}
+
+// reconcile remove keys from index that are no longer in cache.
+// must be called with lock held.
+func (s *shard) reconcile() {
+	for key := range s.lists {
+		if !s.cache.Contains(key) {
+			delete(s.lists, key)
+		}
+	}
+	s.discardEvents()
+}
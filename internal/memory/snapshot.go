@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	SnapshotVersion         int           = 2
+	DefaultSnapshotInterval time.Duration = time.Minute
+
+	snapshotMagic = "SOROBAN-SNAPSHOT"
+)
+
+var (
+	ErrSnapshotHeader  = errors.New("invalid snapshot header")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+)
+
+type snapshotValue struct {
+	Value    string `json:"value"`
+	ExpireOn int64  `json:"expireOn"`
+	Sequence uint64 `json:"sequence,omitempty"` // since version 2
+}
+
+type snapshotKey struct {
+	Key    string          `json:"key"`
+	Values []snapshotValue `json:"values"`
+}
+
+type snapshot struct {
+	Created int64         `json:"created"`
+	Keys    []snapshotKey `json:"keys"`
+}
+
+// EnableSnapshot restore directory from filename and save it periodically.
+// Last snapshot is saved on Close.
+func (m *Memory) EnableSnapshot(filename string, interval time.Duration) error {
+	if len(filename) == 0 {
+		return errors.New("invalid snapshot filename")
+	}
+	if interval <= 0 {
+		interval = DefaultSnapshotInterval
+	}
+
+	err := m.Load(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	m.snapshot = filename
+	m.done = make(chan struct{})
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				if err := m.Save(filename); err != nil {
+					log.WithError(err).Error("Failed to save directory snapshot")
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close save last snapshot if enabled.
+func (m *Memory) Close() error {
+	if len(m.snapshot) == 0 {
+		return nil
+	}
+	close(m.done)
+	m.wg.Wait()
+
+	return m.Save(m.snapshot)
+}
+
+// Save write non-expired values to filename.
+func (m *Memory) Save(filename string) error {
+	now := now()
+	data := snapshot{
+		Created: now.UnixMilli(),
+	}
+	for _, shard := range m.shards {
+		shard.mtx.RLock()
+		for key, list := range shard.lists {
+			item := snapshotKey{
+				Key: key,
+			}
+			for _, value := range list.values {
+				if value.expireOn.Before(now) {
+					continue
+				}
+				item.Values = append(item.Values, snapshotValue{
+					Value:    value.value,
+					ExpireOn: value.expireOn.UnixMilli(),
+					Sequence: value.sequence,
+				})
+			}
+			if len(item.Values) > 0 {
+				data.Keys = append(data.Keys, item)
+			}
+		}
+		shard.mtx.RUnlock()
+	}
+
+	tmpFilename := filename + ".tmp"
+	file, err := os.OpenFile(tmpFilename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	fmt.Fprintf(writer, "%s %d\n", snapshotMagic, SnapshotVersion)
+	err = json.NewEncoder(writer).Encode(&data)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	log.WithField("Keys", len(data.Keys)).Debug("Directory snapshot saved")
+	return os.Rename(tmpFilename, filename)
+}
+
+// Load restore non-expired values from filename.
+func (m *Memory) Load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	var magic string
+	var version int
+	_, err = fmt.Fscanf(reader, "%s %d\n", &magic, &version)
+	if err != nil || magic != snapshotMagic {
+		return ErrSnapshotHeader
+	}
+	// version 1 have no sequence
+	if version < 1 || version > SnapshotVersion {
+		return ErrSnapshotVersion
+	}
+
+	var data snapshot
+	err = json.NewDecoder(reader).Decode(&data)
+	if err != nil {
+		return err
+	}
+
+	// keep sequence increasing after restore
+	for _, item := range data.Keys {
+		for _, value := range item.Values {
+			if value.Sequence > m.sequence.Load() {
+				m.sequence.Store(value.Sequence)
+			}
+		}
+	}
+
+	now := now()
+	count := 0
+	for _, item := range data.Keys {
+		list := &keyList{}
+		for _, value := range item.Values {
+			expireOn := time.UnixMilli(value.ExpireOn).UTC()
+			if expireOn.Before(now) {
+				continue
+			}
+			sequence := value.Sequence
+			if sequence == 0 {
+				sequence = m.sequence.Add(1)
+			}
+			list.values = append(list.values, &valueEntry{
+				value:    value.Value,
+				expireOn: expireOn,
+				sequence: sequence,
+			})
+		}
+		if len(list.values) == 0 {
+			continue
+		}
+
+		shard := m.getShard(item.Key)
+		shard.mtx.Lock()
+		shard.store(item.Key, list, now)
+		shard.mtx.Unlock()
+		count++
+	}
+
+	log.WithField("Keys", count).Info("Directory snapshot loaded")
+	return nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemory_SnapshotRestore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "snapshot")
+
+	m := New(DefaultCacheCapacity, DefaultCacheTTL)
+	if err := m.EnableSnapshot(filename, time.Hour); err != nil {
+		t.Fatalf("EnableSnapshot() error = %v", err)
+	}
+	m.Add("key", "a", time.Minute)
+	m.Add("key", "b", time.Minute)
+	m.Add("expired", "value", time.Second)
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	restored := New(DefaultCacheCapacity, DefaultCacheTTL)
+	if err := restored.EnableSnapshot(filename, time.Hour); err != nil {
+		t.Fatalf("EnableSnapshot() error = %v", err)
+	}
+	defer restored.Close()
+
+	if got, _ := restored.List("key"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("List() = %v, want [a b]", got)
+	}
+	if got, _ := restored.List("expired"); len(got) != 0 {
+		t.Errorf("List() = %v, want empty", got)
+	}
+}
+
+func TestMemory_LoadInvalidHeader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "snapshot")
+
+	tests := []struct {
+		name    string
+		content string
+		want    error
+	}{
+		{"magic", "REDIS 1\n{}\n", ErrSnapshotHeader},
+		{"version", "SOROBAN-SNAPSHOT 42\n{}\n", ErrSnapshotVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(filename, []byte(tt.content), 0600)
+
+			m := New(DefaultCacheCapacity, DefaultCacheTTL)
+			if err := m.Load(filename); err != tt.want {
+				t.Errorf("Load() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultSweepInterval time.Duration = 30 * time.Second
+)
+
+// StartSweeper periodically purge expired values & empty keys until ctx is done.
+func (m *Memory) StartSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug("Exiting directory sweeper")
+			return
+
+		case <-ticker.C:
+			m.Sweep()
+		}
+	}
+}
+
+// Sweep purge expired values & empty keys from all shards.
+func (m *Memory) Sweep() {
+	start := time.Now()
+	now := now()
+
+	var expiredValues, liveKeys, liveValues uint64
+	for _, shard := range m.shards {
+		shard.mtx.Lock()
+		// expire keys from cache
+		shard.cache.GC()
+		shard.accountEvents()
+
+		for key, list := range shard.lists {
+			expiredValues += uint64(purgeKeyList(list, now))
+			if len(list.values) == 0 {
+				shard.delete(key)
+				m.counters.expiredKeys.Add(1)
+				continue
+			}
+			liveKeys++
+			liveValues += uint64(len(list.values))
+		}
+		shard.mtx.Unlock()
+	}
+
+	m.counters.expiredValues.Add(expiredValues)
+	m.counters.liveKeys.Store(liveKeys)
+	m.counters.liveValues.Store(liveValues)
+	m.counters.sweeps.Add(1)
+
+	log.WithFields(log.Fields{
+		"Duration":      time.Since(start),
+		"ExpiredValues": expiredValues,
+		"LiveKeys":      liveKeys,
+		"LiveValues":    liveValues,
+	}).Trace("Directory sweep")
+}
@@ -0,0 +1,157 @@
+// metrics package contains prometheus metrics of soroban
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	soroban "soroban"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	Namespace = "soroban"
+
+	StatusOK = "ok"
+
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+var (
+	// Registry contains all soroban metrics
+	Registry = prometheus.NewRegistry()
+
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by listener type.",
+	}, []string{"listener"})
+
+	RPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "rpc_requests_total",
+		Help:      "RPC requests by method, status and listener type. Status is ok or the json-rpc error code.",
+	}, []string{"method", "status", "listener"})
+
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "RPC requests duration by method and listener type.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "listener"})
+
+	P2PPeers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "p2p_peers",
+		Help:      "Connected P2P peers.",
+	})
+
+	P2PMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "p2p_messages_total",
+		Help:      "P2P messages by direction (in, out).",
+	}, []string{"direction"})
+
+	IPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "ipc_requests_total",
+		Help:      "IPC requests by direction (in, out) and status.",
+	}, []string{"direction", "status"})
+
+	TorUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "tor_up",
+		Help:      "1 if the Tor hidden service is published.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		RPCRequests,
+		RPCDuration,
+		P2PPeers,
+		P2PMessages,
+		IPCRequests,
+		TorUp,
+	)
+}
+
+// Handler serve metrics in prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRPC record rpc request status & duration, code is the json-rpc error code (0 on success).
+func ObserveRPC(method, listener string, code int, start time.Time) {
+	status := StatusOK
+	if code != 0 {
+		status = strconv.Itoa(code)
+	}
+	RPCRequests.WithLabelValues(method, status, listener).Inc()
+	RPCDuration.WithLabelValues(method, listener).Observe(time.Since(start).Seconds())
+}
+
+// HTTPRequestsCount return the http requests counter value of listener
+func HTTPRequestsCount(listener string) uint64 {
+	var metric dto.Metric
+	if err := HTTPRequests.WithLabelValues(listener).Write(&metric); err != nil {
+		return 0
+	}
+	return uint64(metric.GetCounter().GetValue())
+}
+
+// ObserveIPC record ipc request status
+func ObserveIPC(direction string, err error) {
+	status := StatusOK
+	if err != nil {
+		status = "error"
+	}
+	IPCRequests.WithLabelValues(direction, status).Inc()
+}
+
+// RegisterDirectory add directory keys & values gauges from directory status.
+func RegisterDirectory(directory soroban.Directory) error {
+	err := Registry.Register(&directoryCollector{directory: directory})
+	if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return nil
+	}
+	return err
+}
+
+var (
+	directoryKeysDesc   = prometheus.NewDesc(Namespace+"_directory_keys", "Directory keys.", nil, nil)
+	directoryValuesDesc = prometheus.NewDesc(Namespace+"_directory_values", "Directory values.", nil, nil)
+)
+
+// directoryCollector read directory size on scrape
+type directoryCollector struct {
+	directory soroban.Directory
+}
+
+func (c *directoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- directoryKeysDesc
+	ch <- directoryValuesDesc
+}
+
+func (c *directoryCollector) Collect(ch chan<- prometheus.Metric) {
+	status, err := c.directory.Status()
+	if err != nil {
+		return
+	}
+	if keys, err := strconv.ParseFloat(status.Keyspace["keys"], 64); err == nil {
+		ch <- prometheus.MustNewConstMetric(directoryKeysDesc, prometheus.GaugeValue, keys)
+	}
+	if values, err := strconv.ParseFloat(status.Keyspace["values"], 64); err == nil {
+		ch <- prometheus.MustNewConstMetric(directoryValuesDesc, prometheus.GaugeValue, values)
+	}
+}
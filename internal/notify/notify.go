@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"container/list"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	soroban "soroban"
+)
+
+const (
+	OpAdd    = "add"
+	OpRemove = "remove"
+
+	// DefaultEventBuffer is the watcher events channel size
+	DefaultEventBuffer = 16
+	// DefaultHistorySize is the count of events kept per key
+	DefaultHistorySize = 64
+	// DefaultHistoryKeys is the count of keys with events history
+	DefaultHistoryKeys = 4096
+)
+
+type watcher struct {
+	events chan soroban.Event
+}
+
+type watchers map[string]map[*watcher]struct{}
+
+// history is a key bounded event buffer
+type history struct {
+	key    string
+	events []soroban.Event
+	// dropped is the latest event ID no longer in buffer
+	dropped uint64
+}
+
+// Directory notify watchers of changes made through the wrapped directory.
+// Local Add, P2P & IPC messages all go through the same directory.
+// Recent changes are kept by key, least recently changed keys are dropped first.
+type Directory struct {
+	soroban.Directory
+
+	mtx      sync.Mutex
+	sequence uint64
+	keys     watchers
+	prefixes watchers
+
+	history map[string]*list.Element
+	recent  *list.List
+	// dropped is the latest event ID of dropped keys history
+	dropped uint64
+}
+
+// Wrap directory with change notifications
+func Wrap(directory soroban.Directory) *Directory {
+	return &Directory{
+		Directory: directory,
+		keys:      make(watchers),
+		prefixes:  make(watchers),
+		history:   make(map[string]*list.Element),
+		recent:    list.New(),
+	}
+}
+
+// Close wrapped directory if needed
+func (d *Directory) Close() error {
+	if closer, ok := d.Directory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Watch return key changes until cancel is called.
+func (d *Directory) Watch(key string) (<-chan soroban.Event, func()) {
+	return d.watch(d.keys, key)
+}
+
+// WatchPrefix return changes of keys starting with prefix until cancel is called.
+func (d *Directory) WatchPrefix(prefix string) (<-chan soroban.Event, func()) {
+	return d.watch(d.prefixes, prefix)
+}
+
+func (d *Directory) watch(all watchers, key string) (<-chan soroban.Event, func()) {
+	w := &watcher{
+		events: make(chan soroban.Event, DefaultEventBuffer),
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	current, ok := all[key]
+	if !ok {
+		current = make(map[*watcher]struct{})
+		all[key] = current
+	}
+	current[w] = struct{}{}
+
+	var once sync.Once
+	return w.events, func() {
+		once.Do(func() {
+			d.mtx.Lock()
+			defer d.mtx.Unlock()
+
+			delete(all[key], w)
+			if len(all[key]) == 0 {
+				delete(all, key)
+			}
+		})
+	}
+}
+
+// Watchers return the current watchers count
+func (d *Directory) Watchers() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	count := 0
+	for _, current := range d.keys {
+		count += len(current)
+	}
+	for _, current := range d.prefixes {
+		count += len(current)
+	}
+	return count
+}
+
+// Events return known changes after id for key, or keys starting with key if prefix is set.
+func (d *Directory) Events(key string, prefix bool, after uint64) ([]soroban.Event, bool) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var result []soroban.Event
+	complete := after >= d.dropped
+	for name, element := range d.history {
+		if name != key && !(prefix && strings.HasPrefix(name, key)) {
+			continue
+		}
+		h := element.Value.(*history)
+		if after < h.dropped {
+			complete = false
+		}
+		for _, event := range h.events {
+			if event.ID > after {
+				result = append(result, event)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, complete
+}
+
+// Add value in key and notify key watchers.
+func (d *Directory) Add(key, value string, TTL time.Duration) error {
+	err := d.Directory.Add(key, value, TTL)
+	if err != nil {
+		return err
+	}
+	d.notify(soroban.Event{Op: OpAdd, Key: key, Value: value})
+	return nil
+}
+
+// Remove value from key and notify key watchers.
+func (d *Directory) Remove(key, value string) error {
+	err := d.Directory.Remove(key, value)
+	if err != nil {
+		return err
+	}
+	d.notify(soroban.Event{Op: OpRemove, Key: key, Value: value})
+	return nil
+}
+
+// Pop values from key and notify key watchers.
+func (d *Directory) Pop(key string, count int) ([]string, error) {
+	values, err := d.Directory.Pop(key, count)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		d.notify(soroban.Event{Op: OpRemove, Key: key, Value: value})
+	}
+	return values, nil
+}
+
+// notify record event & notify key watchers, never block on slow watchers.
+func (d *Directory) notify(event soroban.Event) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.sequence++
+	event.ID = d.sequence
+	d.record(event)
+
+	for w := range d.keys[event.Key] {
+		send(w, event)
+	}
+	for prefix, current := range d.prefixes {
+		if !strings.HasPrefix(event.Key, prefix) {
+			continue
+		}
+		for w := range current {
+			send(w, event)
+		}
+	}
+}
+
+// record event in key history, must be called with lock held.
+func (d *Directory) record(event soroban.Event) {
+	element, ok := d.history[event.Key]
+	if ok {
+		d.recent.MoveToFront(element)
+	} else {
+		element = d.recent.PushFront(&history{key: event.Key})
+		d.history[event.Key] = element
+	}
+
+	h := element.Value.(*history)
+	if len(h.events) >= DefaultHistorySize {
+		h.dropped = h.events[0].ID
+		h.events = append(h.events[:0], h.events[1:]...)
+	}
+	h.events = append(h.events, event)
+
+	if d.recent.Len() > DefaultHistoryKeys {
+		oldest := d.recent.Back()
+		h := d.recent.Remove(oldest).(*history)
+		delete(d.history, h.key)
+		if last := h.events[len(h.events)-1].ID; last > d.dropped {
+			d.dropped = last
+		}
+	}
+}
+
+func send(w *watcher, event soroban.Event) {
+	select {
+	case w.events <- event:
+	default:
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"soroban/internal/memory"
+)
+
+func TestDirectory_Watch(t *testing.T) {
+	directory := Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+
+	events, cancel := directory.Watch("key")
+	other, cancelOther := directory.Watch("other")
+	defer cancelOther()
+
+	directory.Add("key", "a", directory.TimeToLive("normal"))
+	directory.Remove("key", "a")
+
+	for _, want := range []string{OpAdd, OpRemove} {
+		select {
+		case event := <-events:
+			if event.Op != want || event.Key != "key" || event.Value != "a" {
+				t.Errorf("Watch() event = %v, want %s", event, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Watch() missing %s event", want)
+		}
+	}
+	select {
+	case event := <-other:
+		t.Errorf("Watch() unexpected event = %v", event)
+	default:
+	}
+
+	cancel()
+	cancel()
+	if got := directory.Watchers(); got != 1 {
+		t.Errorf("Watchers() = %d, want 1", got)
+	}
+}
+
+func TestDirectory_Events(t *testing.T) {
+	directory := Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+
+	events, cancel := directory.WatchPrefix("queue.")
+	defer cancel()
+
+	directory.Add("queue.a", "1", directory.TimeToLive("normal"))
+	directory.Add("other", "2", directory.TimeToLive("normal"))
+	directory.Add("queue.b", "3", directory.TimeToLive("normal"))
+
+	for _, want := range []string{"queue.a", "queue.b"} {
+		select {
+		case event := <-events:
+			if event.Key != want {
+				t.Errorf("WatchPrefix() event = %v, want %s", event, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("WatchPrefix() missing %s event", want)
+		}
+	}
+
+	got, complete := directory.Events("queue.", true, 1)
+	if !complete || len(got) != 1 || got[0].Key != "queue.b" || got[0].ID != 3 {
+		t.Errorf("Events() = %v, %v", got, complete)
+	}
+
+	// history is bounded by key
+	for i := 0; i < DefaultHistorySize+1; i++ {
+		directory.Add("other", "2", directory.TimeToLive("normal"))
+	}
+	got, complete = directory.Events("other", false, 2)
+	if complete || len(got) != DefaultHistorySize {
+		t.Errorf("Events() = %d, %v, want %d, false", len(got), complete, DefaultHistorySize)
+	}
+}
@@ -0,0 +1,211 @@
+// ratelimit package contains token bucket rate limiter for soroban requests
+package ratelimit
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ScopeRequest is the scope of every http request
+	ScopeRequest = "request"
+
+	// DefaultCleanupInterval remove idle buckets
+	DefaultCleanupInterval = time.Minute
+	// MaxExhaustedState is the maximum of exhausted buckets reported by State
+	MaxExhaustedState = 100
+	// DefaultMaxBuckets is the maximum count of buckets, requests needing new buckets are limited when reached
+	DefaultMaxBuckets = 100000
+	// fullCleanupInterval remove idle buckets of full limiters
+	fullCleanupInterval = time.Second
+)
+
+// Rates are requests per minute for each scope, zero, negative or missing scopes are unlimited.
+type Rates map[string]int
+
+// Counters of requests for a client & scope
+type Counters struct {
+	Allowed uint64 `json:"allowed"`
+	Limited uint64 `json:"limited"`
+}
+
+// State of the limiter
+type State struct {
+	Rates   Rates `json:"rates"`
+	Buckets int   `json:"buckets"`
+	// Clients are counters per listener & scope
+	Clients map[string]map[string]Counters `json:"clients"`
+	// Exhausted are bucket ids without available tokens
+	Exhausted []string `json:"exhausted"`
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket rate limiter.
+// Buckets are identified by scope, listener, client address and key, bucket capacity is the scope rate per minute.
+type Limiter struct {
+	mtx        sync.Mutex
+	rates      Rates
+	maxBuckets int
+	buckets    map[string]*bucket
+	counters   map[string]map[string]*Counters
+	cleanup    time.Time
+
+	now func() time.Time
+}
+
+func New(rates Rates) *Limiter {
+	return &Limiter{
+		rates:      rates,
+		maxBuckets: DefaultMaxBuckets,
+		buckets:    make(map[string]*bucket),
+		counters:   make(map[string]map[string]*Counters),
+		cleanup:    time.Now(),
+		now:        time.Now,
+	}
+}
+
+// Allow consume a token from listener, client address & key bucket of scope.
+// Clients without address share the listener bucket.
+// Return false if bucket is empty.
+func (p *Limiter) Allow(scope, listener, address, key string) bool {
+	if p == nil {
+		return true
+	}
+	rate := p.rates[scope]
+	if rate <= 0 {
+		return true
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := p.now()
+	interval := DefaultCleanupInterval
+	if len(p.buckets) >= p.maxBuckets {
+		interval = fullCleanupInterval
+	}
+	if now.Sub(p.cleanup) > interval {
+		p.purge(now)
+	}
+
+	counters := p.clientCounters(listener, scope)
+	id := bucketID(scope, listener, address, key)
+	b, ok := p.buckets[id]
+	if !ok {
+		// bucket count is bounded, unique keys can't grow memory
+		if len(p.buckets) >= p.maxBuckets {
+			counters.Limited++
+			return false
+		}
+		b = &bucket{
+			tokens: float64(rate),
+			last:   now,
+		}
+		p.buckets[id] = b
+	}
+	b.refill(rate, now)
+
+	if b.tokens < 1 {
+		counters.Limited++
+		return false
+	}
+	b.tokens--
+	counters.Allowed++
+	return true
+}
+
+// State return a copy of limiter state.
+func (p *Limiter) State() State {
+	result := State{
+		Rates:     make(Rates),
+		Clients:   make(map[string]map[string]Counters),
+		Exhausted: make([]string, 0),
+	}
+	if p == nil {
+		return result
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for scope, rate := range p.rates {
+		result.Rates[scope] = rate
+	}
+	result.Buckets = len(p.buckets)
+	for client, scopes := range p.counters {
+		result.Clients[client] = make(map[string]Counters)
+		for scope, counters := range scopes {
+			result.Clients[client][scope] = *counters
+		}
+	}
+
+	now := p.now()
+	for id, b := range p.buckets {
+		scope := strings.SplitN(id, " ", 2)[0]
+		b.refill(p.rates[scope], now)
+		if b.tokens < 1 {
+			result.Exhausted = append(result.Exhausted, id)
+		}
+	}
+	sort.Strings(result.Exhausted)
+	if len(result.Exhausted) > MaxExhaustedState {
+		result.Exhausted = result.Exhausted[:MaxExhaustedState]
+	}
+	return result
+}
+
+func (p *Limiter) clientCounters(client, scope string) *Counters {
+	scopes, ok := p.counters[client]
+	if !ok {
+		scopes = make(map[string]*Counters)
+		p.counters[client] = scopes
+	}
+	counters, ok := scopes[scope]
+	if !ok {
+		counters = new(Counters)
+		scopes[scope] = counters
+	}
+	return counters
+}
+
+// purge remove full buckets, they are re-created on demand
+func (p *Limiter) purge(now time.Time) {
+	for id, b := range p.buckets {
+		scope := strings.SplitN(id, " ", 2)[0]
+		rate := p.rates[scope]
+		b.refill(rate, now)
+		if b.tokens >= float64(rate) {
+			delete(p.buckets, id)
+		}
+	}
+	p.cleanup = now
+}
+
+func (b *bucket) refill(rate int, now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed.Minutes() * float64(rate)
+	if b.tokens > float64(rate) {
+		b.tokens = float64(rate)
+	}
+	b.last = now
+}
+
+func bucketID(scope, listener, address, key string) string {
+	id := scope + " " + listener
+	if len(address) > 0 {
+		id += " " + address
+	}
+	if len(key) > 0 {
+		id += " " + key
+	}
+	return id
+}
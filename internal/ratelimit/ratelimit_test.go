@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := New(Rates{"directory.Add": 2, "directory.Remove": -1})
+	limiter.now = func() time.Time { return now }
+
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		scope    string
+		listener string
+		address  string
+		key      string
+		want     bool
+	}{
+		{"first", 0, "directory.Add", "IPv4", "10.0.0.1", "foo", true},
+		{"second", 0, "directory.Add", "IPv4", "10.0.0.1", "foo", true},
+		{"exhausted", 0, "directory.Add", "IPv4", "10.0.0.1", "foo", false},
+		{"other-key", 0, "directory.Add", "IPv4", "10.0.0.1", "bar", true},
+		{"other-address", 0, "directory.Add", "IPv4", "10.0.0.2", "foo", true},
+		{"other-listener", 0, "directory.Add", "Tor", "", "foo", true},
+		{"unlimited-scope", 0, "directory.List", "IPv4", "10.0.0.1", "foo", true},
+		{"disabled-scope", 0, "directory.Remove", "IPv4", "10.0.0.1", "foo", true},
+		{"refill", 30 * time.Second, "directory.Add", "IPv4", "10.0.0.1", "foo", true},
+		{"exhausted-again", 0, "directory.Add", "IPv4", "10.0.0.1", "foo", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.elapsed)
+			if got := limiter.Allow(tt.scope, tt.listener, tt.address, tt.key); got != tt.want {
+				t.Errorf("Allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	state := limiter.State()
+	if got := state.Clients["IPv4"]["directory.Add"]; got.Allowed != 5 || got.Limited != 2 {
+		t.Errorf("State() counters = %+v", got)
+	}
+	if len(state.Exhausted) != 1 || state.Exhausted[0] != "directory.Add IPv4 10.0.0.1 foo" {
+		t.Errorf("State() exhausted = %v", state.Exhausted)
+	}
+}
+
+func TestLimiter_MaxBuckets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := New(Rates{"directory.Add": 2})
+	limiter.now = func() time.Time { return now }
+	limiter.maxBuckets = 2
+	limiter.cleanup = now
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		key     string
+		want    bool
+	}{
+		{"first", 0, "a", true},
+		{"second", 0, "b", true},
+		{"full", 0, "c", false},
+		{"existing", 0, "a", true},
+		// a & b buckets are refilled and purged
+		{"purged", time.Minute, "c", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.elapsed)
+			if got := limiter.Allow("directory.Add", "IPv4", "10.0.0.1", tt.key); got != tt.want {
+				t.Errorf("Allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := limiter.State().Buckets; got > 2 {
+		t.Errorf("State() buckets = %d, want at most 2", got)
+	}
+}
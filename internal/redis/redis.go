@@ -0,0 +1,362 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	soroban "soroban"
+	"soroban/internal/common"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultURL     string        = "redis://localhost:6379/0"
+	DefaultTimeout time.Duration = 5 * time.Second
+)
+
+var (
+	// addScript store value with its own expiry as score and its insertion sequence,
+	// purge expired values, apply values limit and set keys expiry to the latest-expiring value.
+	// KEYS: expiry set, order set, sequence counter
+	// ARGV: now, expireOn, value, max values, eviction policy
+	addScript = goredis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+for _, member in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], member)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+
+local result = 1
+local limit = tonumber(ARGV[4])
+if limit > 0 and not redis.call('ZSCORE', KEYS[1], ARGV[3]) and redis.call('ZCARD', KEYS[1]) >= limit then
+	local victim
+	if ARGV[5] == 'oldest' then
+		victim = redis.call('ZRANGE', KEYS[2], 0, 0)[1]
+	elseif ARGV[5] == 'soonest' then
+		victim = redis.call('ZRANGE', KEYS[1], 0, 0)[1]
+	else
+		return 0
+	end
+	redis.call('ZREM', KEYS[1], victim)
+	redis.call('ZREM', KEYS[2], victim)
+	result = 2
+end
+
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+if not redis.call('ZSCORE', KEYS[2], ARGV[3]) then
+	redis.call('ZADD', KEYS[2], redis.call('INCR', KEYS[3]), ARGV[3])
+end
+
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+redis.call('PEXPIREAT', KEYS[1], last[2])
+redis.call('PEXPIREAT', KEYS[2], last[2])
+return result
+`)
+
+	// removeScript remove value and purge expired values.
+	// KEYS: expiry set, order set
+	// ARGV: now, value
+	removeScript = goredis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[2])
+redis.call('ZREM', KEYS[2], ARGV[2])
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+for _, member in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], member)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+return 1
+`)
+
+	// popScript purge expired values, remove and return the first inserted values.
+	// KEYS: expiry set, order set
+	// ARGV: now, count
+	popScript = goredis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+for _, member in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], member)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1])
+
+local values = redis.call('ZRANGE', KEYS[2], 0, tonumber(ARGV[2]) - 1)
+for _, member in ipairs(values) do
+	redis.call('ZREM', KEYS[1], member)
+	redis.call('ZREM', KEYS[2], member)
+end
+return values
+`)
+)
+
+// Redis directory store values in sorted sets, scored by expiry timestamp.
+type Redis struct {
+	domain string
+	limits common.Limits
+	client *goredis.Client
+}
+
+func New(url string) (*Redis, error) {
+	return NewWithDomain("soroban", url)
+}
+
+func NewWithDomain(domain, url string) (*Redis, error) {
+	if len(url) == 0 {
+		url = DefaultURL
+	}
+	options, err := goredis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := goredis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	err = client.Ping(ctx).Err()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &Redis{
+		domain: domain,
+		client: client,
+	}, nil
+}
+
+// SetLimits applied on Add, must be called before use.
+func (r *Redis) SetLimits(limits common.Limits) {
+	r.limits = limits
+}
+
+// Close redis connection
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// Status returs internal informations
+func (r *Redis) Status() (soroban.StatusInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	raw, err := r.client.Info(ctx, "all").Result()
+	if err != nil {
+		return soroban.StatusInfo{}, err
+	}
+
+	return parseInfo(raw), nil
+}
+
+// TimeToLive return duration from mode.
+func (r *Redis) TimeToLive(mode string) time.Duration {
+	return common.TimeToLive(mode)
+}
+
+// List return all known values for this key.
+func (r *Redis) List(key string) ([]string, error) {
+	if len(key) == 0 {
+		return nil, common.InvalidArgsErr
+	}
+
+	key = common.KeyHash(r.domain, key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	// keep non-expired values
+	result, err := r.client.ZRangeByScore(ctx, key, &goredis.ZRangeBy{
+		Min: strconv.FormatInt(now().UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, common.ListErr
+	}
+
+	return result, nil
+}
+
+// Entries return all known values for this key with metadata, in insertion order.
+func (r *Redis) Entries(key string) ([]soroban.Entry, error) {
+	if len(key) == 0 {
+		return nil, common.InvalidArgsErr
+	}
+
+	keys := r.keys(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	var order, expiry *goredis.ZSliceCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		order = pipe.ZRangeWithScores(ctx, keys[1], 0, -1)
+		expiry = pipe.ZRangeByScoreWithScores(ctx, keys[0], &goredis.ZRangeBy{
+			Min: strconv.FormatInt(now().UnixMilli(), 10),
+			Max: "+inf",
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, common.ListErr
+	}
+
+	// keep non-expired values
+	expireOn := make(map[string]time.Time)
+	for _, z := range expiry.Val() {
+		if value, ok := z.Member.(string); ok {
+			expireOn[value] = time.UnixMilli(int64(z.Score)).UTC()
+		}
+	}
+
+	result := make([]soroban.Entry, 0, len(expireOn))
+	for _, z := range order.Val() {
+		value, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		if _, ok := expireOn[value]; !ok {
+			continue
+		}
+		result = append(result, soroban.Entry{
+			Value:    value,
+			Sequence: uint64(z.Score),
+			ExpireOn: expireOn[value],
+		})
+	}
+	return result, nil
+}
+
+// Add value in key.
+// TimeToLive must be greter or equals to 1 second.
+// Multiple values can be store with the same key.
+// Each value expires on its own.
+func (r *Redis) Add(key, value string, TTL time.Duration) error {
+	if len(key) == 0 || len(value) == 0 || TTL < time.Second {
+		return common.InvalidArgsErr
+	}
+	if err := r.limits.Check(key, value); err != nil {
+		return err
+	}
+
+	keys := r.keys(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	now := now()
+	expireOn := now.Add(TTL)
+
+	result, err := addScript.Run(ctx, r.client, keys,
+		now.UnixMilli(), expireOn.UnixMilli(), value,
+		r.limits.MaxValues, r.limits.EvictionPolicy,
+	).Int()
+	if err != nil {
+		return common.AddErr
+	}
+	if result == 0 {
+		return common.KeyFullErr
+	}
+	return nil
+}
+
+// Remove value from key.
+func (r *Redis) Remove(key, value string) error {
+	if len(key) == 0 {
+		return common.InvalidArgsErr
+	}
+
+	keys := r.keys(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	err := removeScript.Run(ctx, r.client, keys[:2], now().UnixMilli(), value).Err()
+	if err != nil {
+		return common.RemoveErr
+	}
+	return nil
+}
+
+// Pop atomically remove and return up to count values from key, in insertion order.
+func (r *Redis) Pop(key string, count int) ([]string, error) {
+	if len(key) == 0 || count < 1 {
+		return nil, common.InvalidArgsErr
+	}
+
+	keys := r.keys(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	result, err := popScript.Run(ctx, r.client, keys[:2], now().UnixMilli(), count).StringSlice()
+	if err != nil {
+		return nil, common.RemoveErr
+	}
+	return result, nil
+}
+
+// keys return expiry set, order set & sequence counter redis keys
+func (r *Redis) keys(key string) []string {
+	return []string{
+		common.KeyHash(r.domain, key),
+		common.OrderHash(r.domain, key),
+		common.CountHash(r.domain, "sequence"),
+	}
+}
+
+// parseInfo split redis INFO output by sections
+func parseInfo(raw string) soroban.StatusInfo {
+	result := soroban.StatusInfo{
+		Raw: raw,
+	}
+
+	var section soroban.NameValue
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			section = make(soroban.NameValue)
+			switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#"))) {
+			case "clients":
+				result.Clients = section
+			case "cluster":
+				result.Cluster = section
+			case "commandstats":
+				result.Commandstats = section
+			case "cpu":
+				result.CPU = section
+			case "keyspace":
+				result.Keyspace = section
+			case "memory":
+				result.Memory = section
+			case "persistence":
+				result.Persistence = section
+			case "replication":
+				result.Replication = section
+			case "server":
+				result.Server = section
+			case "stats":
+				result.Stats = section
+			}
+			continue
+		}
+		if section == nil {
+			continue
+		}
+		toks := strings.SplitN(line, ":", 2)
+		if len(toks) != 2 {
+			continue
+		}
+		section[toks[0]] = toks[1]
+	}
+	return result
+}
+
+var now = func() time.Time {
+	return time.Now().Truncate(time.Millisecond).UTC()
+}
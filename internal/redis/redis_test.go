@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"soroban/internal/common"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedis use REDIS_URL if set, in-process stand-in otherwise
+func newTestRedis(t *testing.T) *Redis {
+	url := os.Getenv("REDIS_URL")
+	if len(url) == 0 {
+		server := miniredis.RunT(t)
+		url = "redis://" + server.Addr()
+	}
+
+	directory, err := NewWithDomain("soroban-test-"+t.Name(), url)
+	if err != nil {
+		t.Fatalf("NewWithDomain() error = %v", err)
+	}
+	t.Cleanup(func() {
+		directory.Close()
+	})
+	return directory
+}
+
+func TestRedis_AddListRemove(t *testing.T) {
+	directory := newTestRedis(t)
+
+	tests := []struct {
+		name   string
+		add    []string
+		remove []string
+		want   []string
+	}{
+		{"empty", nil, nil, []string{}},
+		{"single", []string{"a"}, nil, []string{"a"}},
+		{"duplicates", []string{"a", "b", "a"}, nil, []string{"a", "b"}},
+		{"remove", []string{"a", "b", "c"}, []string{"b"}, []string{"a", "c"}},
+		{"remove-all", []string{"a"}, []string{"a"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "key." + tt.name
+			for _, value := range tt.add {
+				if err := directory.Add(key, value, time.Minute); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+			for _, value := range tt.remove {
+				if err := directory.Remove(key, value); err != nil {
+					t.Fatalf("Remove() error = %v", err)
+				}
+			}
+			got, err := directory.List(key)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedis_PerValueExpiry(t *testing.T) {
+	directory := newTestRedis(t)
+
+	if err := directory.Add("key", "long", directory.TimeToLive("long")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := directory.Add("key", "fast", directory.TimeToLive("fast")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// move clock after fast expiry
+	saved := now
+	defer func() { now = saved }()
+	start := now()
+	now = func() time.Time {
+		return start.Add(directory.TimeToLive("fast") + time.Second)
+	}
+
+	got, err := directory.List("key")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if want := []string{"long"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestRedis_InvalidArgs(t *testing.T) {
+	directory := newTestRedis(t)
+
+	if err := directory.Add("", "value", time.Minute); err == nil {
+		t.Error("Add() with empty key must fail")
+	}
+	if err := directory.Add("key", "value", time.Millisecond); err == nil {
+		t.Error("Add() with TTL lower than 1 second must fail")
+	}
+	if _, err := directory.List(""); err == nil {
+		t.Error("List() with empty key must fail")
+	}
+}
+
+func Test_parseInfo(t *testing.T) {
+	raw := "# Server\r\nredis_version:5.0.14\r\n\r\n# Keyspace\r\ndb0:keys=2,expires=2,avg_ttl=0\r\n"
+
+	got := parseInfo(raw)
+	if got.Server["redis_version"] != "5.0.14" {
+		t.Errorf("parseInfo() Server = %v", got.Server)
+	}
+	if got.Keyspace["db0"] != "keys=2,expires=2,avg_ttl=0" {
+		t.Errorf("parseInfo() Keyspace = %v", got.Keyspace)
+	}
+	if got.Raw != raw {
+		t.Errorf("parseInfo() Raw = %v", got.Raw)
+	}
+}
+
+func TestRedis_Limits(t *testing.T) {
+	directory := newTestRedis(t)
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr error
+		want    []string
+	}{
+		{"reject", "reject", common.KeyFullErr, []string{"a", "b", "c"}},
+		{"oldest", "oldest", nil, []string{"b", "c", "d"}},
+		{"soonest", "soonest", nil, []string{"a", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "key." + tt.name
+			directory.SetLimits(common.Limits{})
+			directory.Add(key, "a", directory.TimeToLive("long"))
+			directory.Add(key, "b", directory.TimeToLive("fast"))
+			directory.Add(key, "c", directory.TimeToLive("normal"))
+
+			directory.SetLimits(common.Limits{MaxValues: 3, EvictionPolicy: tt.policy})
+			if err := directory.Add(key, "d", directory.TimeToLive("normal")); err != tt.wantErr {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			got, _ := directory.List(key)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedis_Pop(t *testing.T) {
+	directory := newTestRedis(t)
+	for _, value := range []string{"a", "b", "c"} {
+		directory.Add("key", value, directory.TimeToLive("normal"))
+	}
+
+	got, err := directory.Pop("key", 2)
+	if err != nil {
+		t.Fatalf("Pop() error = %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pop() = %v, want %v", got, want)
+	}
+
+	got, _ = directory.List("key")
+	if want := []string{"c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
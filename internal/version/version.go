@@ -1,4 +1,5 @@
-package main
+// version package contains build information of soroban binaries, set with -ldflags -X
+package version
 
 import (
 	"fmt"
@@ -12,7 +13,8 @@ var (
 	Version string
 )
 
-func printVersionExit() {
+// PrintExit print build information and exit
+func PrintExit() {
 	fmt.Printf("Version: %s\nCommit: %s\nRuntime: %s %s/%s\nDate: %s\n",
 		Version,
 		Commit,
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"soroban/internal/metrics"
+
 	"github.com/nats-io/nats.go"
 	log "github.com/sirupsen/logrus"
 )
@@ -82,6 +84,7 @@ func (p *IPCService) Request(request Message, direction string) (Message, error)
 	subject := fmt.Sprintf("%s.%s", p.options.Subject, direction)
 	log.WithField("subject", subject).Debug("IPC Requests")
 	msg, err := p.conn.Request(subject, data, 5*time.Second)
+	metrics.ObserveIPC(metrics.DirectionOut, err)
 	if err != nil {
 		return Message{}, err
 	}
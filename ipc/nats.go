@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"soroban/internal/metrics"
+
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 	log "github.com/sirupsen/logrus"
@@ -108,6 +110,7 @@ func handleNatsMessage(ctx context.Context, msg *nats.Msg, handler MessageHandle
 		log.WithError(err).
 			WithField("Data", string(msg.Data)).
 			Warning("Failed to Unmarshal message")
+		metrics.ObserveIPC(metrics.DirectionIn, err)
 		response = Message{
 			Type:    "error",
 			Message: "Failed to Unmarshal message",
@@ -124,6 +127,7 @@ func handleNatsMessage(ctx context.Context, msg *nats.Msg, handler MessageHandle
 	}
 
 	response, err = handler(ctx, message)
+	metrics.ObserveIPC(metrics.DirectionIn, err)
 	if err != nil {
 		log.WithError(err).
 			Error("Message handler failed")
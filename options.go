@@ -11,19 +11,34 @@ var (
 		LogLevel: "info",
 		LogFile:  "-",
 		Soroban: SorobanInfo{
-			Config:         "",
-			Confidential:   "",
-			Domain:         "soroban",
-			DirectoryType:  "default",
-			WithTor:        false,
-			OnionFile:      "-",
-			Seed:           "",
-			Hostname:       "localhost",
-			Port:           4242,
-			Announce:       "soroban.announce.nodes",
-			IPv4:           false,
-			StatsEndpoint:  "",
-			StatusEndpoint: "",
+			Config:          "",
+			Confidential:    "",
+			Domain:          "soroban",
+			DirectoryType:   "default",
+			RedisURL:        "redis://localhost:6379/0",
+			DataDir:         "/var/lib/soroban",
+			Snapshot:        "",
+			MaxKeyLength:    512,
+			MaxEntrySize:    64 * 1024,
+			MaxValues:       1000,
+			EvictionPolicy:  "reject",
+			RateLimit:       0,
+			RateLimitAdd:    0,
+			RateLimitList:   0,
+			WithTor:         false,
+			OnionFile:       "-",
+			Seed:            "",
+			Hostname:        "localhost",
+			Port:            4242,
+			Announce:        "soroban.announce.nodes",
+			IPv4:            false,
+			StatsEndpoint:   "",
+			StatusEndpoint:  "",
+			MetricsEndpoint: "",
+			AdminListen:     "",
+			AdminPublicKey:  "",
+			AdminAlgorithm:  "",
+			AuditLog:        "",
 		},
 		P2P: P2PInfo{
 			Seed:          "",
@@ -96,19 +111,34 @@ func (p *Options) Merge(o Options) {
 }
 
 type SorobanInfo struct {
-	Config         string
-	Confidential   string
-	Domain         string
-	DirectoryType  string
-	WithTor        bool
-	OnionFile      string
-	Seed           string
-	Hostname       string
-	Port           int
-	Announce       string
-	IPv4           bool
-	StatsEndpoint  string
-	StatusEndpoint string
+	Config          string
+	Confidential    string
+	Domain          string
+	DirectoryType   string
+	RedisURL        string
+	DataDir         string
+	Snapshot        string
+	MaxKeyLength    int
+	MaxEntrySize    int
+	MaxValues       int
+	EvictionPolicy  string
+	RateLimit       int
+	RateLimitAdd    int
+	RateLimitList   int
+	WithTor         bool
+	OnionFile       string
+	Seed            string
+	Hostname        string
+	Port            int
+	Announce        string
+	IPv4            bool
+	StatsEndpoint   string
+	StatusEndpoint  string
+	MetricsEndpoint string
+	AdminListen     string
+	AdminPublicKey  string
+	AdminAlgorithm  string
+	AuditLog        string
 }
 
 func (p *SorobanInfo) Merge(s SorobanInfo) {
@@ -124,6 +154,38 @@ func (p *SorobanInfo) Merge(s SorobanInfo) {
 	if len(s.DirectoryType) > 0 {
 		p.DirectoryType = s.DirectoryType
 	}
+	if len(s.RedisURL) > 0 {
+		p.RedisURL = s.RedisURL
+	}
+	if len(s.DataDir) > 0 {
+		p.DataDir = s.DataDir
+	}
+	if len(s.Snapshot) > 0 {
+		p.Snapshot = s.Snapshot
+	}
+	// zero is unset, negative limits disable the limit
+	if s.MaxKeyLength != 0 {
+		p.MaxKeyLength = s.MaxKeyLength
+	}
+	if s.MaxEntrySize != 0 {
+		p.MaxEntrySize = s.MaxEntrySize
+	}
+	if s.MaxValues != 0 {
+		p.MaxValues = s.MaxValues
+	}
+	if len(s.EvictionPolicy) > 0 {
+		p.EvictionPolicy = s.EvictionPolicy
+	}
+	// zero is unset, negative rate limits disable the limit
+	if s.RateLimit != 0 {
+		p.RateLimit = s.RateLimit
+	}
+	if s.RateLimitAdd != 0 {
+		p.RateLimitAdd = s.RateLimitAdd
+	}
+	if s.RateLimitList != 0 {
+		p.RateLimitList = s.RateLimitList
+	}
 	if s.WithTor {
 		p.WithTor = s.WithTor
 	}
@@ -151,6 +213,21 @@ func (p *SorobanInfo) Merge(s SorobanInfo) {
 	if len(s.StatusEndpoint) > 0 {
 		p.StatusEndpoint = s.StatusEndpoint
 	}
+	if len(s.MetricsEndpoint) > 0 {
+		p.MetricsEndpoint = s.MetricsEndpoint
+	}
+	if len(s.AdminListen) > 0 {
+		p.AdminListen = s.AdminListen
+	}
+	if len(s.AdminPublicKey) > 0 {
+		p.AdminPublicKey = s.AdminPublicKey
+	}
+	if len(s.AdminAlgorithm) > 0 {
+		p.AdminAlgorithm = s.AdminAlgorithm
+	}
+	if len(s.AuditLog) > 0 {
+		p.AuditLog = s.AuditLog
+	}
 }
 
 type P2PInfo struct {
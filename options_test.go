@@ -0,0 +1,28 @@
+package soroban
+
+import "testing"
+
+func TestSorobanInfo_Merge_Limits(t *testing.T) {
+	options := DefaultOptions.Soroban
+	options.RateLimit = 6000
+
+	var config Options
+	err := config.parse([]byte("soroban:\n  maxkeylength: -1\n  maxentrysize: 0\n  maxvalues: 10\n  ratelimit: -1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Merge(config.Soroban)
+
+	if options.MaxKeyLength != -1 {
+		t.Errorf("MaxKeyLength = %d, want -1", options.MaxKeyLength)
+	}
+	if options.MaxEntrySize != DefaultOptions.Soroban.MaxEntrySize {
+		t.Errorf("MaxEntrySize = %d, want default", options.MaxEntrySize)
+	}
+	if options.MaxValues != 10 {
+		t.Errorf("MaxValues = %d, want 10", options.MaxValues)
+	}
+	if options.RateLimit != -1 {
+		t.Errorf("RateLimit = %d, want -1", options.RateLimit)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	soroban "soroban"
+	"soroban/internal/metrics"
 
 	"github.com/libp2p/go-libp2p"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
@@ -32,6 +33,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	ErrNotStarted = errors.New("p2p not started")
+)
+
 // P2P for distributed soroban
 type P2P struct {
 	OnMessage chan Message
@@ -184,6 +189,7 @@ func (p *P2P) Start(ctx context.Context, optionsP2P soroban.P2PInfo, optionsGoss
 	}
 
 	go p.subscribe(ctx, subscriber)
+	go p.countPeers(ctx)
 
 	// Start persisting the peerstore
 	if optionsP2P.PeerstoreFile != "-" {
@@ -213,11 +219,26 @@ func (p *P2P) subscribe(ctx context.Context, subscriber *pubsub.Subscription) {
 			log.Debug("Skip unkown message")
 			continue
 		}
+		metrics.P2PMessages.WithLabelValues(metrics.DirectionIn).Inc()
 
 		p.OnMessage <- message
 	}
 }
 
+// countPeers update connected peers metric
+func (p *P2P) countPeers(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		metrics.P2PPeers.Set(float64(len(p.host.Network().Peers())))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // Publish to topic
 func (p *P2P) Publish(ctx context.Context, msg string) error {
 	if len(msg) == 0 {
@@ -227,6 +248,7 @@ func (p *P2P) Publish(ctx context.Context, msg string) error {
 		return nil
 	}
 	p.topic.Publish(ctx, []byte(msg))
+	metrics.P2PMessages.WithLabelValues(metrics.DirectionOut).Inc()
 	return nil
 }
 
@@ -261,7 +283,22 @@ func StartPeerstorePersistence(ctx context.Context, optionsP2P soroban.P2PInfo,
 	}
 }
 
+// Peers return connected peers with their known addresses
+func (p *P2P) Peers() []peer.AddrInfo {
+	if p.host == nil {
+		return nil
+	}
+	var result []peer.AddrInfo
+	for _, peerId := range p.host.Network().Peers() {
+		result = append(result, p.host.Network().Peerstore().PeerInfo(peerId))
+	}
+	return result
+}
+
 func (p *P2P) PersistPeerstore(ctx context.Context, optionsP2P soroban.P2PInfo) error {
+	if p.host == nil {
+		return ErrNotStarted
+	}
 	var peersAddrs []peer.AddrInfo
 	for _, peerId := range p.host.Network().Peerstore().PeersWithAddrs() {
 		if p.host.ID() == peerId {
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"soroban/internal"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	AdminListener ListenerType = internal.AdminListener
+
+	unixPrefix = "unix:"
+)
+
+var (
+	ErrAdminNotLocal = errors.New("admin listener must be localhost or unix socket")
+)
+
+// adminListen listen on unix socket (unix:/path) or localhost address.
+func adminListen(address string) (net.Listener, error) {
+	if strings.HasPrefix(address, unixPrefix) {
+		path := strings.TrimPrefix(address, unixPrefix)
+		// remove stale socket
+		os.Remove(path)
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		err = os.Chmod(path, 0600)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		return listener, nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, ErrAdminNotLocal
+	}
+	return net.Listen("tcp", address)
+}
+
+// startAdminServer serve json-rpc on the admin listener, admin service is allowed on this listener only.
+func (p *Soroban) startAdminServer(address string) {
+	listener, err := adminListen(address)
+	if err != nil {
+		log.WithError(err).WithField("Address", address).Error("Failed to start admin listener")
+		return
+	}
+
+	router := mux.NewRouter()
+	router.Handle("/rpc", NewRPCHandler(p.rpcServer))
+
+	log.WithField("Address", address).Info("Admin RPC listener started")
+	adminServer := p.createHttpServer("", router, AdminListener)
+	err = adminServer.Serve(listener)
+	if err != http.ErrServerClosed {
+		log.WithError(err).Error("Admin Http Server exited")
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"soroban/internal/common"
+
+	"github.com/gorilla/rpc"
+)
+
+var null = json.RawMessage([]byte("null"))
+
+// serverRequest represents a json-rpc request received by the server.
+// Version is set by json-rpc 2.0 clients.
+type serverRequest struct {
+	Version string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  *json.RawMessage `json:"params"`
+	Id      *json.RawMessage `json:"id"`
+}
+
+// UnmarshalJSON keep null ids, Id is nil only when id is missing (notifications).
+func (r *serverRequest) UnmarshalJSON(data []byte) error {
+	type plain serverRequest
+	var request struct {
+		plain
+		Id json.RawMessage `json:"id"`
+	}
+	err := json.Unmarshal(data, &request)
+	if err != nil {
+		return err
+	}
+	*r = serverRequest(request.plain)
+	r.Id = nil
+	if request.Id != nil {
+		id := request.Id
+		r.Id = &id
+	}
+	return nil
+}
+
+// serverResponse represents a json-rpc 1.0 response returned by the server.
+type serverResponse struct {
+	Result interface{}      `json:"result"`
+	Error  interface{}      `json:"error"`
+	Id     *json.RawMessage `json:"id"`
+}
+
+// serverResponse2 represents a json-rpc 2.0 response returned by the server.
+// Result and Error are mutually exclusive.
+type serverResponse2 struct {
+	Version string           `json:"jsonrpc"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *common.Error    `json:"error,omitempty"`
+	Id      *json.RawMessage `json:"id"`
+}
+
+// Codec is a json-rpc 1.0 & 2.0 codec writing errors as {code, message} objects.
+type Codec struct{}
+
+func NewCodec() *Codec {
+	return &Codec{}
+}
+
+// NewRequest returns a CodecRequest.
+func (c *Codec) NewRequest(r *http.Request) rpc.CodecRequest {
+	req := new(serverRequest)
+	err := json.NewDecoder(r.Body).Decode(req)
+	r.Body.Close()
+	return &CodecRequest{request: req, err: err}
+}
+
+// CodecRequest decodes and encodes a single request.
+type CodecRequest struct {
+	request *serverRequest
+	err     error
+}
+
+// Method returns the RPC method for the current request.
+func (c *CodecRequest) Method() (string, error) {
+	if c.err == nil {
+		return c.request.Method, nil
+	}
+	return "", c.err
+}
+
+// ReadRequest fills the request object for the RPC method.
+func (c *CodecRequest) ReadRequest(args interface{}) error {
+	if c.err == nil {
+		// params are optional, args are left empty when missing
+		if c.request.Params != nil {
+			// JSON params is array value. RPC params is struct.
+			params := [1]interface{}{args}
+			c.err = json.Unmarshal(*c.request.Params, &params)
+		}
+	}
+	return c.err
+}
+
+// WriteResponse encodes the response and writes it to the ResponseWriter.
+// Response format follows the request json-rpc version.
+func (c *CodecRequest) WriteResponse(w http.ResponseWriter, reply interface{}, methodErr error) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.request.Id == nil {
+		// Id is missing for notifications and they don't have a response.
+		return nil
+	}
+
+	var res interface{}
+	if c.request.Version == Version2 {
+		res2 := &serverResponse2{
+			Version: Version2,
+			Result:  reply,
+			Id:      c.request.Id,
+		}
+		if methodErr != nil {
+			res2.Error = common.AsError(methodErr)
+			res2.Result = nil
+		}
+		res = res2
+	} else {
+		res1 := &serverResponse{
+			Result: reply,
+			Error:  &null,
+			Id:     c.request.Id,
+		}
+		if methodErr != nil {
+			res1.Error = common.AsError(methodErr)
+			// Result must be null if there was an error invoking the method.
+			res1.Result = &null
+		}
+		res = res1
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	c.err = json.NewEncoder(w).Encode(res)
+	return c.err
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"soroban/internal/common"
+
+	"github.com/gorilla/rpc"
+)
+
+type TestService struct{}
+
+type TestArgs struct {
+	Fail string
+}
+
+func (t *TestService) Call(r *http.Request, args *TestArgs, result *string) error {
+	switch args.Fail {
+	case "signature":
+		return common.SignatureErr
+	case "unknown":
+		return errors.New("unknown")
+	}
+	*result = "success"
+	return nil
+}
+
+func TestCodec_WriteResponse(t *testing.T) {
+	server := rpc.NewServer()
+	server.RegisterCodec(NewCodec(), "application/json")
+	server.RegisterService(new(TestService), "test")
+
+	tests := []struct {
+		name string
+		fail string
+		want string
+	}{
+		{"success", "", `{"result":"success","error":null,"id":1}`},
+		{"coded", "signature", `{"result":null,"error":{"code":-32001,"message":"Invalid Signature Error"},"id":1}`},
+		{"internal", "unknown", `{"result":null,"error":{"code":-32603,"message":"unknown"},"id":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"method":"test.Call","params":[{"Fail":"` + tt.fail + `"}],"id":1}`
+			r := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("response = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
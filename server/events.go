@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	soroban "soroban"
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/services"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EventsEventReset is sent when resumed events are no longer known
+	EventsEventReset = "reset"
+
+	eventsHeartbeatInterval = 15 * time.Second
+)
+
+// eventsEpoch identify this process in event IDs ("epoch-sequence"),
+// sequences restart at 0 with the process and IDs of previous processes are reset.
+var eventsEpoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// parseEventID return the sequence of id, known is false if id is from another process.
+func parseEventID(id string) (after uint64, known bool, err error) {
+	epoch, sequence, found := strings.Cut(id, "-")
+	if !found {
+		// id without epoch
+		_, err = strconv.ParseUint(id, 10, 64)
+		return 0, false, err
+	}
+	after, err = strconv.ParseUint(sequence, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	if epoch != eventsEpoch {
+		return 0, false, nil
+	}
+	return after, true, nil
+}
+
+// eventsAuth check confidential keys access with List signature.
+// verified prefixes are cached for the stream lifetime, signature is used once per stream.
+type eventsAuth struct {
+	ctx      context.Context
+	args     services.DirectoryEntries
+	verified map[string]bool
+	used     bool
+}
+
+func (p *eventsAuth) allowed(key string) bool {
+	info := confidential.GetConfidentialInfo(key, p.args.PublicKey)
+	if !info.Confidential {
+		return true
+	}
+	if result, ok := p.verified[info.Prefix]; ok {
+		return result
+	}
+	err := p.args.VerifySignature("directory.List", info)
+	if err == nil && !p.used {
+		err = p.args.VerifyReplay(p.ctx, "directory.List", info)
+		p.used = err == nil
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to verifySignature")
+	}
+	p.verified[info.Prefix] = err == nil
+	return err == nil
+}
+
+// EventsHandler stream directory changes as Server-Sent Events.
+// Query: name or prefix, and publicKey, algorithm, signature, timestamp & signatureVersion for confidential keys.
+// Streams are resumed with Last-Event-ID header from the directory events history.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	directory := internal.DirectoryFromContext(ctx)
+	if directory == nil {
+		http.Error(w, "Directory not found", http.StatusInternalServerError)
+		return
+	}
+	eventLog, ok := directory.(soroban.EventLog)
+	if !ok {
+		http.Error(w, "Events not supported", http.StatusNotImplemented)
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("name")
+	prefix := len(name) == 0
+	if prefix {
+		name = query.Get("prefix")
+	}
+	if len(name) == 0 {
+		http.Error(w, "Invalid name", http.StatusBadRequest)
+		return
+	}
+	timestamp, _ := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+	signatureVersion, _ := strconv.Atoi(query.Get("signatureVersion"))
+	auth := eventsAuth{
+		ctx: r.Context(),
+		args: services.DirectoryEntries{
+			Name:      name,
+			PublicKey: query.Get("publicKey"),
+			Algorithm: query.Get("algorithm"),
+			Signature: query.Get("signature"),
+			Timestamp: timestamp,
+
+			SignatureVersion: signatureVersion,
+		},
+		verified: make(map[string]bool),
+	}
+	if !auth.allowed(name) {
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if len(lastEventID) == 0 {
+		lastEventID = query.Get("lastEventId")
+	}
+	var after uint64
+	known := true
+	if len(lastEventID) > 0 {
+		var err error
+		after, known, err = parseEventID(lastEventID)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// streams share held requests limits with directory.Wait
+	release, err := services.AcquireWait(ctx)
+	if err != nil {
+		http.Error(w, "Too many streams", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	// watch before reading history, changes are not lost
+	var events <-chan soroban.Event
+	var cancel func()
+	if prefix {
+		events, cancel = eventLog.WatchPrefix(name)
+	} else {
+		events, cancel = eventLog.Watch(name)
+	}
+	defer cancel()
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(event soroban.Event) bool {
+		if event.ID <= after {
+			return true
+		}
+		after = event.ID
+		if !auth.allowed(event.Key) {
+			return true
+		}
+		data, err := json.Marshal(&SubscribeEvent{Event: event.Op, Name: event.Key, Entry: event.Value})
+		if err != nil {
+			return false
+		}
+		_, err = fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", eventsEpoch, event.ID, event.Op, data)
+		return err == nil
+	}
+
+	if len(lastEventID) > 0 {
+		// events of another process are unknown, changes since start are sent after reset
+		history, complete := eventLog.Events(name, prefix, after)
+		if !complete || !known {
+			fmt.Fprintf(w, "event: %s\ndata: {\"Event\":\"%s\"}\n\n", EventsEventReset, EventsEventReset)
+		}
+		for _, event := range history {
+			if !write(event) {
+				return
+			}
+		}
+	}
+	if controller.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if !write(event) {
+				return
+			}
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+
+		if controller.Flush() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"soroban/internal"
+	"soroban/internal/memory"
+	"soroban/internal/notify"
+	"soroban/services"
+)
+
+func TestEventsHandler(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+	directory.Add("queue.a", "1", directory.TimeToLive("normal"))
+	directory.Add("queue.b", "2", directory.TimeToLive("normal"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), internal.SorobanDirectoryKey, directory)
+		EventsHandler(w, r.WithContext(ctx))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?prefix=queue.", nil)
+	// resume after first event
+	request.Header.Set("Last-Event-ID", eventsEpoch+"-1")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer response.Body.Close()
+	if got := response.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", got)
+	}
+
+	directory.Add("other", "3", directory.TimeToLive("normal"))
+	directory.Remove("queue.a", "1")
+
+	want := []string{
+		"id: " + eventsEpoch + "-2", "event: add", `data: {"Event":"add","Name":"queue.b","Entry":"2"}`, "",
+		"id: " + eventsEpoch + "-4", "event: remove", `data: {"Event":"remove","Name":"queue.a","Entry":"1"}`, "",
+	}
+	scanner := bufio.NewScanner(response.Body)
+	for _, line := range want {
+		if !scanner.Scan() {
+			t.Fatalf("Scan() error = %v", scanner.Err())
+		}
+		if got := strings.TrimSpace(scanner.Text()); got != line {
+			t.Errorf("line = %q, want %q", got, line)
+		}
+	}
+}
+
+func TestEventsHandler_Restarted(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+	directory.Add("queue.a", "1", directory.TimeToLive("normal"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), internal.SorobanDirectoryKey, directory)
+		EventsHandler(w, r.WithContext(ctx))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		lastEventID string
+	}{
+		{"previous-process", "previous-5000"},
+		{"without-epoch", "5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?name=queue.a", nil)
+			request.Header.Set("Last-Event-ID", tt.lastEventID)
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer response.Body.Close()
+
+			// client is reset, and receive events since start
+			want := []string{
+				"event: reset", `data: {"Event":"reset"}`, "",
+				"id: " + eventsEpoch + "-1", "event: add", `data: {"Event":"add","Name":"queue.a","Entry":"1"}`, "",
+			}
+			scanner := bufio.NewScanner(response.Body)
+			for _, line := range want {
+				if !scanner.Scan() {
+					t.Fatalf("Scan() error = %v", scanner.Err())
+				}
+				if got := strings.TrimSpace(scanner.Text()); got != line {
+					t.Errorf("line = %q, want %q", got, line)
+				}
+			}
+		})
+	}
+}
+
+func TestEventsHandler_PerAddressLimit(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), internal.SorobanDirectoryKey, directory)
+		EventsHandler(w, r.WithContext(ctx))
+	}))
+	server.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, internal.SorobanConnKey, &internal.ConnInfo{
+			Listener: string(IPv4Listener),
+			Address:  connAddress(c, IPv4Listener),
+		})
+	}
+	server.Start()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// each held stream use its own connection
+	for i := 0; i <= services.MaxWaitPerAddress; i++ {
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?name=queue.a", nil)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		defer response.Body.Close()
+
+		want := http.StatusOK
+		if i == services.MaxWaitPerAddress {
+			want = http.StatusTooManyRequests
+		}
+		if response.StatusCode != want {
+			t.Errorf("stream %d status = %d, want %d", i, response.StatusCode, want)
+		}
+	}
+}
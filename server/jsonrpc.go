@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"soroban/internal/common"
+	"soroban/internal/metrics"
+
+	"github.com/gorilla/rpc"
+)
+
+const (
+	Version2 = "2.0"
+
+	MaxRequestSize = 1 << 20
+	MaxBatchSize   = 32
+)
+
+var (
+	parseErr          = common.NewError(common.CodeParseError, "Parse Error")
+	invalidRequestErr = common.NewError(common.CodeInvalidRequest, "Invalid Request Error")
+	methodNotFoundErr = common.NewError(common.CodeMethodNotFound, "Method Not Found Error")
+	batchTooLargeErr  = common.NewError(common.CodeInvalidRequest, "Batch Too Large Error")
+	batchWaitErr      = common.NewError(common.CodeInvalidRequest, "Wait Not Allowed In Batch Error")
+)
+
+// batchForbidden methods hold requests and can't be batched,
+// batch entries are called one after another.
+var batchForbidden = []string{"directory.Wait"}
+
+// RPCHandler serve json-rpc 1.0 & 2.0 requests, including 2.0 batches.
+// Each request is dispatched to the rpc server, invalid requests
+// are answered with json-rpc errors.
+type RPCHandler struct {
+	server *rpc.Server
+}
+
+func NewRPCHandler(server *rpc.Server) *RPCHandler {
+	return &RPCHandler{
+		server: server,
+	}
+}
+
+func (h *RPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.server.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestSize+1))
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > MaxRequestSize {
+		http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		writeResponse(w, h.call(r, body, false))
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeResponse(w, errorResponse(nil, parseErr))
+		return
+	}
+	if len(batch) == 0 {
+		writeResponse(w, errorResponse(nil, invalidRequestErr))
+		return
+	}
+	if len(batch) > MaxBatchSize {
+		writeResponse(w, errorResponse(nil, batchTooLargeErr))
+		return
+	}
+
+	responses := make([]json.RawMessage, 0, len(batch))
+	for _, raw := range batch {
+		if response := h.call(r, raw, true); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		// batch of notifications
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	data, err := json.Marshal(responses)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, data)
+}
+
+// call dispatch a single request, return nil for notifications.
+// Notifications are requests without id member, requests with a null id are answered.
+func (h *RPCHandler) call(r *http.Request, raw []byte, batch bool) []byte {
+	if !json.Valid(raw) {
+		return errorResponse(nil, parseErr)
+	}
+
+	var request serverRequest
+	err := json.Unmarshal(raw, &request)
+	if err != nil || len(request.Method) == 0 {
+		// invalid requests are always answered
+		return errorResponse(request.Id, invalidRequestErr)
+	}
+	if batch {
+		for _, method := range batchForbidden {
+			if strings.EqualFold(request.Method, method) {
+				return requestError(&request, batchWaitErr)
+			}
+		}
+	}
+	start := time.Now()
+	if !h.server.HasMethod(request.Method) {
+		method, ok := h.exportedMethod(request.Method)
+		if !ok {
+			metrics.ObserveRPC("unknown", listenerLabel(r), common.CodeMethodNotFound, start)
+			return requestError(&request, methodNotFoundErr)
+		}
+		raw, err = setMethod(raw, method)
+		if err != nil {
+			return requestError(&request, invalidRequestErr)
+		}
+		request.Method = method
+	}
+
+	// response is nil for notifications
+	response := h.dispatch(r, raw, &request)
+	metrics.ObserveRPC(request.Method, listenerLabel(r), responseCode(response), start)
+	return response
+}
+
+// responseCode return json-rpc error code of response, 0 without error
+func responseCode(response []byte) int {
+	var result struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if len(response) == 0 || json.Unmarshal(response, &result) != nil {
+		return 0
+	}
+	if len(result.Error) == 0 || string(result.Error) == "null" {
+		return 0
+	}
+	var err common.Error
+	if json.Unmarshal(result.Error, &err) != nil || err.Code == 0 {
+		return common.CodeInternal
+	}
+	return err.Code
+}
+
+// listenerLabel return request listener type for metrics
+func listenerLabel(r *http.Request) string {
+	listenerType, _ := r.Context().Value(ListenerTypeKey).(ListenerType)
+	return string(listenerType)
+}
+
+// exportedMethod return the registered method name for lower case methods (ie rpc.discover).
+func (h *RPCHandler) exportedMethod(method string) (string, bool) {
+	index := strings.LastIndex(method, ".")
+	if index < 0 || index == len(method)-1 {
+		return "", false
+	}
+	method = method[:index+1] + strings.ToUpper(method[index+1:index+2]) + method[index+2:]
+	return method, h.server.HasMethod(method)
+}
+
+// setMethod replace request method of raw request.
+func setMethod(raw []byte, method string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, err
+	}
+	fields["method"], err = json.Marshal(method)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// dispatch request to rpc server and return its response.
+func (h *RPCHandler) dispatch(r *http.Request, raw []byte, request *serverRequest) []byte {
+	req := r.Clone(r.Context())
+	req.Body = io.NopCloser(bytes.NewReader(raw))
+	req.ContentLength = int64(len(raw))
+	req.Header.Set("Content-Type", "application/json")
+
+	recorder := &responseRecorder{
+		header: make(http.Header),
+		status: http.StatusOK,
+	}
+	h.server.ServeHTTP(recorder, req)
+
+	if recorder.status != http.StatusOK {
+		// rpc server errors are plain text, mostly invalid params
+		message := strings.TrimSpace(recorder.body.String())
+		return requestError(request, common.NewError(common.CodeInvalidArgs, message))
+	}
+	if recorder.body.Len() == 0 {
+		return nil
+	}
+	return bytes.TrimSpace(recorder.body.Bytes())
+}
+
+// requestError return an error response following request json-rpc version, nil for notifications.
+func requestError(request *serverRequest, err *common.Error) []byte {
+	if request.Id == nil {
+		return nil
+	}
+	if request.Version == Version2 {
+		return errorResponse(request.Id, err)
+	}
+	data, _ := json.Marshal(&serverResponse{
+		Result: &null,
+		Error:  err,
+		Id:     request.Id,
+	})
+	return data
+}
+
+// errorResponse return a json-rpc 2.0 error response
+func errorResponse(id *json.RawMessage, err *common.Error) []byte {
+	if id == nil {
+		id = &null
+	}
+	data, _ := json.Marshal(&serverResponse2{
+		Version: Version2,
+		Error:   err,
+		Id:      id,
+	})
+	return data
+}
+
+func writeResponse(w http.ResponseWriter, data []byte) {
+	if data == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("x-content-type-options", "nosniff")
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// responseRecorder buffer rpc server response
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/rpc"
+)
+
+func TestRPCHandler(t *testing.T) {
+	server := rpc.NewServer()
+	server.RegisterCodec(NewCodec(), "application/json")
+	server.RegisterService(new(TestService), "test")
+	handler := NewRPCHandler(server)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       string
+	}{
+		{
+			"v1",
+			`{"method":"test.Call","params":[{}],"id":1}`,
+			http.StatusOK,
+			`{"result":"success","error":null,"id":1}`,
+		},
+		{
+			"v2",
+			`{"jsonrpc":"2.0","method":"test.Call","params":[{}],"id":"a"}`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","result":"success","id":"a"}`,
+		},
+		{
+			"v2-error",
+			`{"jsonrpc":"2.0","method":"test.Call","params":[{"Fail":"signature"}],"id":1}`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32001,"message":"Invalid Signature Error"},"id":1}`,
+		},
+		{
+			"notification",
+			`{"jsonrpc":"2.0","method":"test.Call","params":[{}]}`,
+			http.StatusNoContent,
+			``,
+		},
+		{
+			"parse-error",
+			`{"jsonrpc":"2.0","method"`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse Error"},"id":null}`,
+		},
+		{
+			"method-not-found",
+			`{"jsonrpc":"2.0","method":"test.Unknown","params":[{}],"id":1}`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method Not Found Error"},"id":1}`,
+		},
+		{
+			"lower-case-method",
+			`{"jsonrpc":"2.0","method":"test.call","id":1}`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","result":"success","id":1}`,
+		},
+		{
+			"batch",
+			`[{"jsonrpc":"2.0","method":"test.Call","params":[{}],"id":1},{"jsonrpc":"2.0","method":"test.Call","params":[{}]},1,{"jsonrpc":"2.0","method":"test.Call","params":[{"Fail":"unknown"}],"id":2}]`,
+			http.StatusOK,
+			`[{"jsonrpc":"2.0","result":"success","id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request Error"},"id":null},{"jsonrpc":"2.0","error":{"code":-32603,"message":"unknown"},"id":2}]`,
+		},
+		{
+			"null-id",
+			`{"jsonrpc":"2.0","method":"test.Call","params":[{}],"id":null}`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","result":"success","id":null}`,
+		},
+		{
+			"batch-wait",
+			`[{"jsonrpc":"2.0","method":"directory.wait","params":[{}],"id":1}]`,
+			http.StatusOK,
+			`[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Wait Not Allowed In Batch Error"},"id":1}]`,
+		},
+		{
+			"empty-batch",
+			`[]`,
+			http.StatusOK,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request Error"},"id":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("response = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
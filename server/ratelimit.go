@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/internal/ratelimit"
+)
+
+// NewRateLimiter return limiter with requests, add & list budgets per minute.
+// Write methods share the add budget, read methods share the list budget,
+// each method & key has its own bucket.
+func NewRateLimiter(requests, add, list int) *ratelimit.Limiter {
+	return ratelimit.New(ratelimit.Rates{
+		ratelimit.ScopeRequest: requests,
+		"directory.Add":        add,
+		"directory.Remove":     add,
+		"directory.Pop":        add,
+		"directory.List":       list,
+		"directory.Wait":       list,
+	})
+}
+
+// RateLimitMiddleware limit requests per listener type & remote IP.
+// Tor clients can't be distinguished, they share the Tor listener budget.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listenerType, _ := r.Context().Value(ListenerTypeKey).(ListenerType)
+		var address string
+		if conn := internal.ConnInfoFromContext(r.Context()); conn != nil {
+			address = conn.Address
+		}
+		if !limiter.Allow(ratelimit.ScopeRequest, string(listenerType), address, "") {
+			w.Header().Set("Retry-After", "60")
+			restWrite(w, http.StatusTooManyRequests, common.RateLimitErr)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"soroban/internal/common"
+	"soroban/internal/metrics"
+	"soroban/services"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// signature headers for confidential & readonly keys
+const (
+	HeaderPublicKey = "X-Soroban-PublicKey"
+	HeaderAlgorithm = "X-Soroban-Algorithm"
+	HeaderSignature = "X-Soroban-Signature"
+	HeaderTimestamp = "X-Soroban-Timestamp"
+	HeaderNonce     = "X-Soroban-Nonce"
+
+	HeaderSignatureVersion = "X-Soroban-Signature-Version"
+)
+
+// RESTEntry is the POST /v1/directory/{name} body
+type RESTEntry struct {
+	Entry string
+	Mode  string
+}
+
+// RegisterRESTHandlers add /v1/directory resources to router, using directory json-rpc service.
+func RegisterRESTHandlers(router *mux.Router, middleware func(http.Handler) http.Handler) {
+	v1 := router.PathPrefix("/v1/directory").Subrouter()
+	v1.Handle("/{name}", middleware(http.HandlerFunc(restList))).Methods(http.MethodGet)
+	v1.Handle("/{name}", middleware(http.HandlerFunc(restAdd))).Methods(http.MethodPost)
+	v1.Handle("/{name}", middleware(http.HandlerFunc(restRemove))).Methods(http.MethodDelete)
+	v1.Handle("/{name}/{entry}", middleware(http.HandlerFunc(restRemove))).Methods(http.MethodDelete)
+}
+
+// restList handle GET /v1/directory/{name}
+// Query: limit, ordered, cursor, pageSize, since as for directory.List
+func restList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+	ordered, _ := strconv.ParseBool(query.Get("ordered"))
+
+	publicKey, algorithm, signature, timestamp := restSignature(r)
+	args := services.DirectoryEntries{
+		Name:      mux.Vars(r)["name"],
+		Limit:     limit,
+		Ordered:   ordered,
+		Cursor:    query.Get("cursor"),
+		PageSize:  pageSize,
+		Since:     query.Get("since"),
+		PublicKey: publicKey,
+		Algorithm: algorithm,
+		Signature: signature,
+		Timestamp: timestamp,
+
+		SignatureVersion: restSignatureVersion(r),
+	}
+
+	var result services.DirectoryEntriesResponse
+	start := time.Now()
+	err := new(services.Directory).List(r, &args, &result)
+	metrics.ObserveRPC("directory.List", listenerLabel(r), errorCode(err), start)
+	if err != nil {
+		restError(w, err)
+		return
+	}
+	restWrite(w, http.StatusOK, &result)
+}
+
+// restAdd handle POST /v1/directory/{name}
+func restAdd(w http.ResponseWriter, r *http.Request) {
+	var entry RESTEntry
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestSize)).Decode(&entry)
+	if err != nil {
+		restError(w, common.InvalidArgsErr)
+		return
+	}
+
+	publicKey, algorithm, signature, timestamp := restSignature(r)
+	args := services.DirectoryEntry{
+		Name:      mux.Vars(r)["name"],
+		Entry:     entry.Entry,
+		Mode:      entry.Mode,
+		PublicKey: publicKey,
+		Algorithm: algorithm,
+		Signature: signature,
+		Timestamp: timestamp,
+		Nonce:     r.Header.Get(HeaderNonce),
+
+		SignatureVersion: restSignatureVersion(r),
+	}
+
+	var result services.Response
+	start := time.Now()
+	err = new(services.Directory).Add(r, &args, &result)
+	metrics.ObserveRPC("directory.Add", listenerLabel(r), errorCode(err), start)
+	if err != nil {
+		restError(w, err)
+		return
+	}
+	restWrite(w, http.StatusCreated, &result)
+}
+
+// restRemove handle DELETE /v1/directory/{name}/{entry}, or DELETE /v1/directory/{name}
+// with entry in body for entries which can't be in path (ie containing "/" or too large).
+func restRemove(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entry, ok := vars["entry"]
+	if !ok {
+		var body RESTEntry
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestSize)).Decode(&body)
+		if err != nil {
+			restError(w, common.InvalidArgsErr)
+			return
+		}
+		entry = body.Entry
+	}
+
+	publicKey, algorithm, signature, timestamp := restSignature(r)
+	args := services.DirectoryEntry{
+		Name:      vars["name"],
+		Entry:     entry,
+		PublicKey: publicKey,
+		Algorithm: algorithm,
+		Signature: signature,
+		Timestamp: timestamp,
+
+		SignatureVersion: restSignatureVersion(r),
+	}
+
+	var result services.Response
+	start := time.Now()
+	err := new(services.Directory).Remove(r, &args, &result)
+	metrics.ObserveRPC("directory.Remove", listenerLabel(r), errorCode(err), start)
+	if err != nil {
+		restError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func restSignatureVersion(r *http.Request) int {
+	version, _ := strconv.Atoi(r.Header.Get(HeaderSignatureVersion))
+	return version
+}
+
+func restSignature(r *http.Request) (string, string, string, int64) {
+	timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	return r.Header.Get(HeaderPublicKey), r.Header.Get(HeaderAlgorithm), r.Header.Get(HeaderSignature), timestamp
+}
+
+// restStatus return http status from json-rpc error code
+func restStatus(err *common.Error) int {
+	switch err.Code {
+	case common.CodeInvalidArgs, common.CodeInvalidRequest, common.CodeParseError:
+		return http.StatusBadRequest
+	case common.CodeInvalidSignature, common.CodeTimestampOutRange, common.CodeInvalidStamp, common.CodeReplayed:
+		return http.StatusUnauthorized
+	case common.CodeForbidden:
+		return http.StatusForbidden
+	case common.CodeQuotaExceeded, common.CodeRateLimited:
+		return http.StatusTooManyRequests
+	case common.CodeMethodNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCode return json-rpc error code of err, 0 if nil
+func errorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return common.AsError(err).Code
+}
+
+func restError(w http.ResponseWriter, err error) {
+	rpcErr := common.AsError(err)
+	restWrite(w, restStatus(rpcErr), rpcErr)
+}
+
+func restWrite(w http.ResponseWriter, status int, result interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(result)
+	if err != nil {
+		log.WithError(err).Debug("Failed to write response")
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"soroban/internal"
+	"soroban/internal/memory"
+	"soroban/p2p"
+	"soroban/services"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRESTHandlers(t *testing.T) {
+	directory := memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	router := mux.NewRouter()
+	RegisterRESTHandlers(router, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), internal.SorobanDirectoryKey, directory)
+			ctx = context.WithValue(ctx, internal.SorobanP2PKey, &p2p.P2P{})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantList   []string
+	}{
+		{"add", http.MethodPost, "/v1/directory/key", `{"Entry":"a","Mode":"normal"}`, http.StatusCreated, []string{"a"}},
+		{"add-invalid", http.MethodPost, "/v1/directory/key", `{"Entry":""}`, http.StatusBadRequest, []string{"a"}},
+		{"add-malformed", http.MethodPost, "/v1/directory/key", `{`, http.StatusBadRequest, []string{"a"}},
+		{"remove", http.MethodDelete, "/v1/directory/key/a", ``, http.StatusNoContent, []string{}},
+		{"add-slash", http.MethodPost, "/v1/directory/key", `{"Entry":"http://a/b","Mode":"normal"}`, http.StatusCreated, []string{"http://a/b"}},
+		{"remove-slash", http.MethodDelete, "/v1/directory/key", `{"Entry":"http://a/b"}`, http.StatusNoContent, []string{}},
+		{"remove-malformed", http.MethodDelete, "/v1/directory/key", `{`, http.StatusBadRequest, []string{}},
+		{"method", http.MethodPut, "/v1/directory/key", ``, http.StatusMethodNotAllowed, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/directory/key", nil))
+			var result services.DirectoryEntriesResponse
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if strings.Join(result.Entries, ",") != strings.Join(tt.wantList, ",") {
+				t.Errorf("List() = %v, want %v", result.Entries, tt.wantList)
+			}
+		})
+	}
+}
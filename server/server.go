@@ -17,6 +17,9 @@ import (
 	soroban "soroban"
 	"soroban/confidential"
 	"soroban/internal"
+	"soroban/internal/metrics"
+	"soroban/internal/notify"
+	"soroban/internal/ratelimit"
 	"soroban/ipc"
 	"soroban/p2p"
 	"soroban/services"
@@ -24,7 +27,6 @@ import (
 	"github.com/cretz/bine/tor"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/rpc"
-	gjson "github.com/gorilla/rpc/json"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
 )
@@ -37,6 +39,10 @@ type Soroban struct {
 	onion     *tor.OnionService
 	started   chan bool
 	rpcServer *rpc.Server
+	limiter   *ratelimit.Limiter
+
+	metricsLabel string
+	adminListen  string
 }
 
 func New(ctx context.Context, options soroban.Options) (context.Context, *Soroban) {
@@ -48,13 +54,25 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 
 	switch options.Soroban.DirectoryType {
 	case "memory":
-		directory = internal.NewDirectory(options.Soroban.Domain, internal.DirectoryTypeMemory)
+		directory = internal.NewDirectory(ctx, options.Soroban, internal.DirectoryTypeMemory)
+	case "redis":
+		directory = internal.NewDirectory(ctx, options.Soroban, internal.DirectoryTypeRedis)
+	case "disk":
+		directory = internal.NewDirectory(ctx, options.Soroban, internal.DirectoryTypeDisk)
 	case "default":
-		directory = internal.DefaultDirectory(options.Soroban.Domain)
+		directory = internal.DefaultDirectory(ctx, options.Soroban)
 	}
 	if directory == nil {
 		log.Fatal("Invalid Directory")
 	}
+	confidential.SetDomain(options.Soroban.Domain)
+
+	// notify waiting clients of directory changes
+	directory = notify.Wrap(directory)
+	err := metrics.RegisterDirectory(directory)
+	if err != nil {
+		log.WithError(err).Error("Failed to register directory metrics")
+	}
 
 	startIPCService := options.IPC.ChildProcessCount > 0 && options.IPC.ChildID == 0
 	startMainSoroban := startIPCService || (options.IPC.ChildProcessCount == 0 && options.IPC.ChildID == 0)
@@ -205,10 +223,10 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 
 	rpcServer := rpc.NewServer()
 
-	rpcServer.RegisterCodec(gjson.NewCodec(), "application/json")
-	rpcServer.RegisterCodec(gjson.NewCodec(), "application/json;charset=UTF-8")
+	rpcServer.RegisterCodec(NewCodec(), "application/json")
+	rpcServer.RegisterCodec(NewCodec(), "application/json;charset=UTF-8")
 
-	http.Handle("/rpc", rpcServer)
+	http.Handle("/rpc", NewRPCHandler(rpcServer))
 
 	return ctx, &Soroban{
 		p2p:       internal.P2PFromContext(ctx),
@@ -217,6 +235,10 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 		started:   make(chan bool),
 		rpcServer: rpcServer,
 		directory: directory,
+		limiter:   NewRateLimiter(options.Soroban.RateLimit, options.Soroban.RateLimitAdd, options.Soroban.RateLimitList),
+
+		metricsLabel: options.Soroban.MetricsEndpoint,
+		adminListen:  options.Soroban.AdminListen,
 	}
 }
 
@@ -276,6 +298,7 @@ func (p *Soroban) StartWithTor(ctx context.Context, hostname string, port int, s
 	if err != nil {
 		return err
 	}
+	metrics.TorUp.Set(1)
 
 	// start with listener
 	go p.startServer(hostname, port, p.onion, statsLabel, statusLabel)
@@ -285,18 +308,25 @@ func (p *Soroban) StartWithTor(ctx context.Context, hostname string, port int, s
 
 func (p *Soroban) startServer(hostname string, port int, listener net.Listener, statsLabel string, statusLabel string) {
 	p.started <- true
+	if len(p.adminListen) > 0 {
+		go p.startAdminServer(p.adminListen)
+	}
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Use your allowed origin here
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization", "User-Agent"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "User-Agent", HeaderPublicKey, HeaderAlgorithm, HeaderSignature, HeaderTimestamp, HeaderNonce, HeaderSignatureVersion},
 		AllowCredentials: true,
 	})
 
 	stats := NewStats()
-	rpcHandler := WrapHandler(stats.Middleware((p.rpcServer)))
+	stats.RateLimiter = p.limiter
+	rpcHandler := WrapHandler(stats.Middleware(NewRPCHandler(p.rpcServer)))
 
 	router := mux.NewRouter()
 	router.HandleFunc("/rpc", rpcHandler)
+	router.HandleFunc("/ws", SubscribeHandler)
+	router.HandleFunc("/events", EventsHandler).Methods(http.MethodGet)
+	RegisterRESTHandlers(router, stats.Middleware)
 	if len(statsLabel) > 0 {
 		router.HandleFunc("/"+statsLabel, stats.StatsHandler)
 		log.Info("RPC API /stats endpoint activated and accessible at: /" + statsLabel)
@@ -305,7 +335,11 @@ func (p *Soroban) startServer(hostname string, port int, listener net.Listener,
 		router.HandleFunc("/"+statusLabel, StatusHandler)
 		log.Info("RPC API /status endpoint activated and accessible at: /" + statusLabel)
 	}
-	mainHandler := c.Handler(router)
+	if len(p.metricsLabel) > 0 {
+		router.Handle("/"+p.metricsLabel, metrics.Handler())
+		log.Info("Prometheus /metrics endpoint activated and accessible at: /" + p.metricsLabel)
+	}
+	mainHandler := c.Handler(RateLimitMiddleware(p.limiter, router))
 
 	if listener != nil {
 		go func() {
@@ -326,9 +360,17 @@ func (p *Soroban) startServer(hostname string, port int, listener net.Listener,
 }
 
 func (p *Soroban) Stop(ctx context.Context) {
+	if closer, ok := p.directory.(io.Closer); ok {
+		err := closer.Close()
+		if err != nil {
+			log.WithError(err).Error("Fails to Close directory")
+		}
+	}
+
 	if p.onion == nil {
 		return
 	}
+	metrics.TorUp.Set(0)
 	err := p.onion.Close()
 	if err != nil {
 		log.WithError(err).Error("Fails to Close tor")
@@ -346,6 +388,19 @@ func (p *Soroban) WaitForStart(ctx context.Context) {
 	<-p.started
 }
 
+// connAddress return remote IP of connection.
+// Tor connections come from the local tor daemon, they have no address.
+func connAddress(c net.Conn, listenerType ListenerType) string {
+	if listenerType == TorListener {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(c.RemoteAddr().String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
 func (p *Soroban) createHttpServer(addr string, handler http.Handler, listenerType ListenerType) http.Server {
 
 	return http.Server{
@@ -353,6 +408,13 @@ func (p *Soroban) createHttpServer(addr string, handler http.Handler, listenerTy
 
 		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
 			ctx = context.WithValue(ctx, internal.SorobanDirectoryKey, p.directory)
+			ctx = context.WithValue(ctx, internal.SorobanConnKey, &internal.ConnInfo{
+				Listener: string(listenerType),
+				Address:  connAddress(c, listenerType),
+			})
+			if p.limiter != nil {
+				ctx = context.WithValue(ctx, internal.SorobanRateLimitKey, p.limiter)
+			}
 			if p.p2p != nil {
 				ctx = context.WithValue(ctx, internal.SorobanP2PKey, p.p2p)
 			}
@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"soroban/internal/metrics"
+	"soroban/internal/ratelimit"
 )
 
 type ContextKey string
@@ -16,130 +19,146 @@ const (
 
 	IPv4Listener ListenerType = "IPv4"
 	TorListener  ListenerType = "Tor"
+
+	// StatsWindow is the longest duration reported by /stats
+	StatsWindow = 24 * time.Hour
 )
 
-type Stats struct {
-	sync.RWMutex
-	IPv4 []int64
-	Tor  []int64
+// statsPeriods reported by /stats
+var statsPeriods = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"last_01m", time.Minute},
+	{"last_15m", 15 * time.Minute},
+	{"last_30m", 30 * time.Minute},
+	{"last_1h", time.Hour},
+	{"last_2h", 2 * time.Hour},
+	{"last_3h", 3 * time.Hour},
+	{"last_6h", 6 * time.Hour},
+	{"last_12h", 12 * time.Hour},
+	{"last_24h", 24 * time.Hour},
 }
 
-func NewStats() *Stats {
-	return &Stats{
-		IPv4: make([]int64, 0),
-		Tor:  make([]int64, 0),
-	}
+// requestWindow sample the prometheus requests counter of a listener at the start of each minute over StatsWindow.
+// Requests of last minutes are the difference between the counter and the sample of the first minute.
+type requestWindow struct {
+	listener string
+	starts   [int(StatsWindow / time.Minute)]uint64
+	last     int64
 }
 
-func (s *Stats) RecordRequest(listenerType ListenerType) {
-	s.Lock()
-	defer s.Unlock()
-	now := time.Now().Unix()
-
-	switch listenerType {
-	case IPv4Listener:
-		s.IPv4 = append(s.IPv4, now)
+func newRequestWindow(listener ListenerType, minute int64) *requestWindow {
+	p := &requestWindow{
+		listener: string(listener),
+		last:     minute,
+	}
+	value := metrics.HTTPRequestsCount(p.listener)
+	for i := range p.starts {
+		p.starts[i] = value
+	}
+	return p
+}
 
-	case TorListener:
-		s.Tor = append(s.Tor, now)
+// advance sample counter for minutes elapsed since last call, requests are recorded after advance
+func (p *requestWindow) advance(minute int64) uint64 {
+	value := metrics.HTTPRequestsCount(p.listener)
+	size := int64(len(p.starts))
+	if minute <= p.last {
+		return value
+	}
+	from := p.last + 1
+	if minute-p.last > size {
+		from = minute - size + 1
+	}
+	for m := from; m <= minute; m++ {
+		p.starts[m%size] = value
 	}
+	p.last = minute
+	return value
 }
 
-func (s *Stats) CountRequests(listenerType ListenerType, duration time.Duration) int {
-	s.RLock()
-	defer s.RUnlock()
-	now := time.Now().Unix()
-	threshold := now - int64(duration.Seconds())
-	count := 0
-
-	requests := []int64{}
-	switch listenerType {
-	case IPv4Listener:
-		requests = s.IPv4
-
-	case TorListener:
-		requests = s.Tor
+// count requests of last minutes, including current minute
+func (p *requestWindow) count(minute int64, minutes int64) uint64 {
+	value := p.advance(minute)
+	size := int64(len(p.starts))
+	if minutes > size {
+		minutes = size
+	}
+	if minutes <= 0 {
+		return 0
 	}
+	return value - p.starts[(minute-minutes+1)%size]
+}
+
+// Stats count requests per listener type from prometheus metrics, with fixed memory.
+type Stats struct {
+	sync.Mutex
+	requests map[ListenerType]*requestWindow
 
-	for _, v := range requests {
-		if v >= threshold {
-			count++
-		}
+	RateLimiter *ratelimit.Limiter
+
+	now func() time.Time
+}
+
+func NewStats() *Stats {
+	return newStats(time.Now)
+}
+
+func newStats(now func() time.Time) *Stats {
+	minute := now().Unix() / 60
+	return &Stats{
+		requests: map[ListenerType]*requestWindow{
+			IPv4Listener: newRequestWindow(IPv4Listener, minute),
+			TorListener:  newRequestWindow(TorListener, minute),
+		},
+		now: now,
 	}
-	return count
 }
 
-func (s *Stats) Cleanup(duration time.Duration) {
+func (s *Stats) minute() int64 {
+	return s.now().Unix() / 60
+}
+
+func (s *Stats) RecordRequest(listenerType ListenerType) {
 	s.Lock()
 	defer s.Unlock()
-	startDate := time.Now().Add(-1 * duration).Unix()
-	thresholdIndex := 0
-
-	// Remove older entries
-	for i, v := range s.IPv4 {
-		if v > startDate {
-			thresholdIndex = i
-			break
-		}
+	if window, ok := s.requests[listenerType]; ok {
+		window.advance(s.minute())
 	}
-	s.IPv4 = s.IPv4[thresholdIndex:]
+	metrics.HTTPRequests.WithLabelValues(string(listenerType)).Inc()
+}
 
-	for i, v := range s.Tor {
-		if v > startDate {
-			thresholdIndex = i
-			break
-		}
+func (s *Stats) CountRequests(listenerType ListenerType, duration time.Duration) int {
+	s.Lock()
+	defer s.Unlock()
+	window, ok := s.requests[listenerType]
+	if !ok {
+		return 0
 	}
-	s.Tor = s.Tor[thresholdIndex:]
+	return int(window.count(s.minute(), int64(duration/time.Minute)))
 }
 
 func (s *Stats) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		listenerType := r.Context().Value(ListenerTypeKey).(ListenerType)
+		listenerType, _ := r.Context().Value(ListenerTypeKey).(ListenerType)
 		s.RecordRequest(listenerType)
 		next.ServeHTTP(w, r)
 	})
 }
 
 func (s *Stats) StatsHandler(w http.ResponseWriter, r *http.Request) {
-	s.Cleanup(24 * time.Hour)
-
-	ipv4 := map[string]int{
-		"last_01m": s.CountRequests(IPv4Listener, time.Minute),
-		"last_15m": s.CountRequests(IPv4Listener, 15*time.Minute),
-		"last_30m": s.CountRequests(IPv4Listener, 30*time.Minute),
-		"last_1h":  s.CountRequests(IPv4Listener, time.Hour),
-		"last_2h":  s.CountRequests(IPv4Listener, 2*time.Hour),
-		"last_3h":  s.CountRequests(IPv4Listener, 3*time.Hour),
-		"last_6h":  s.CountRequests(IPv4Listener, 6*time.Hour),
-		"last_12h": s.CountRequests(IPv4Listener, 12*time.Hour),
-		"last_24h": s.CountRequests(IPv4Listener, 24*time.Hour),
-	}
-
-	tor := map[string]int{
-		"last_01m": s.CountRequests(TorListener, time.Minute),
-		"last_15m": s.CountRequests(TorListener, 15*time.Minute),
-		"last_30m": s.CountRequests(TorListener, 30*time.Minute),
-		"last_1h":  s.CountRequests(TorListener, time.Hour),
-		"last_2h":  s.CountRequests(TorListener, 2*time.Hour),
-		"last_3h":  s.CountRequests(TorListener, 3*time.Hour),
-		"last_6h":  s.CountRequests(TorListener, 6*time.Hour),
-		"last_12h": s.CountRequests(TorListener, 12*time.Hour),
-		"last_24h": s.CountRequests(TorListener, 24*time.Hour),
-	}
-
+	ipv4 := make(map[string]int)
+	tor := make(map[string]int)
 	response := map[string]interface{}{
-		"last_01m": ipv4["last_01m"] + tor["last_01m"],
-		"last_15m": ipv4["last_15m"] + tor["last_15m"],
-		"last_30m": ipv4["last_30m"] + tor["last_30m"],
-		"last_1h":  ipv4["last_1h"] + tor["last_1h"],
-		"last_2h":  ipv4["last_2h"] + tor["last_2h"],
-		"last_3h":  ipv4["last_3h"] + tor["last_6h"],
-		"last_6h":  ipv4["last_6h"] + tor["last_3h"],
-		"last_12h": ipv4["last_12h"] + tor["last_12h"],
-		"last_24h": ipv4["last_24h"] + tor["last_24h"],
-		"ipv4":     ipv4,
-		"tor":      tor,
+		"ipv4":      ipv4,
+		"tor":       tor,
+		"ratelimit": s.RateLimiter.State(),
+	}
+	for _, period := range statsPeriods {
+		ipv4[period.Label] = s.CountRequests(IPv4Listener, period.Duration)
+		tor[period.Label] = s.CountRequests(TorListener, period.Duration)
+		response[period.Label] = ipv4[period.Label] + tor[period.Label]
 	}
 
 	jsonResponse, err := json.Marshal(response)
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats_CountRequests(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	stats := newStats(func() time.Time { return now })
+
+	// one request per listener every hour, then one request per minute over the last hour
+	for i := 0; i < 30; i++ {
+		stats.RecordRequest(IPv4Listener)
+		now = now.Add(time.Hour)
+	}
+	for i := 0; i < 60; i++ {
+		stats.RecordRequest(TorListener)
+		now = now.Add(time.Minute)
+	}
+	now = now.Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		listenerType ListenerType
+		duration     time.Duration
+		want         int
+	}{
+		{"ipv4-1h", IPv4Listener, time.Hour, 0},
+		{"ipv4-3h", IPv4Listener, 3 * time.Hour, 2},
+		{"ipv4-6h", IPv4Listener, 6 * time.Hour, 5},
+		{"ipv4-24h", IPv4Listener, 24 * time.Hour, 23},
+		{"tor-1m", TorListener, time.Minute, 1},
+		{"tor-15m", TorListener, 15 * time.Minute, 15},
+		{"tor-24h", TorListener, 24 * time.Hour, 60},
+		{"ipv4-after-window", IPv4Listener, 48 * time.Hour, 23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stats.CountRequests(tt.listenerType, tt.duration); got != tt.want {
+				t.Errorf("CountRequests() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
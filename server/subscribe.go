@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	soroban "soroban"
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/services"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	SubscribeActionSubscribe   = "subscribe"
+	SubscribeActionUnsubscribe = "unsubscribe"
+
+	SubscribeEventSubscribed   = "subscribed"
+	SubscribeEventUnsubscribed = "unsubscribed"
+	SubscribeEventAdd          = "add"
+	SubscribeEventRemove       = "remove"
+	SubscribeEventExpire       = "expire"
+	SubscribeEventError        = "error"
+
+	MaxSubscriptionsPerConn = 16
+
+	subscribeWriteTimeout = 10 * time.Second
+	subscribePingInterval = 30 * time.Second
+	subscribeReadTimeout  = 2 * subscribePingInterval
+	subscribeMaxMessage   = 4096
+)
+
+// SubscribeRequest is sent by websocket clients.
+// Signature is required for confidential keys, as for directory.List
+type SubscribeRequest struct {
+	Action    string
+	Name      string
+	PublicKey string
+	Algorithm string
+	Signature string
+	Timestamp int64
+	// SignatureVersion is confidential.SignatureV1 or confidential.SignatureV2
+	SignatureVersion int `json:",omitempty"`
+}
+
+// SubscribeEvent is pushed to websocket clients.
+type SubscribeEvent struct {
+	Event string
+	Name  string
+	Entry string `json:",omitempty"`
+	Code  int    `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// same policy as cors for rpc
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type subscription struct {
+	entries map[string]time.Time
+	cancel  func()
+	done    chan struct{}
+}
+
+// SubscribeHandler push directory key changes to websocket clients.
+// Current entries are sent as add events on subscribe.
+func SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	directory := internal.DirectoryFromContext(ctx)
+	if directory == nil {
+		http.Error(w, "Directory not found", http.StatusInternalServerError)
+		return
+	}
+	watcher, ok := directory.(soroban.Watcher)
+	if !ok {
+		http.Error(w, "Subscriptions not supported", http.StatusNotImplemented)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.WithError(err).Debug("Failed to upgrade websocket")
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(subscribeMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(subscribeReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(subscribeReadTimeout))
+	})
+
+	// reader goroutine, all writes are done by this goroutine
+	requests := make(chan SubscribeRequest)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var request SubscribeRequest
+			err := conn.ReadJSON(&request)
+			if err != nil {
+				return
+			}
+			select {
+			case requests <- request:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	subscriptions := make(map[string]*subscription)
+	defer func() {
+		for _, sub := range subscriptions {
+			sub.cancel()
+			close(sub.done)
+		}
+	}()
+	changed := make(chan string)
+
+	ping := time.NewTicker(subscribePingInterval)
+	defer ping.Stop()
+	expiry := time.NewTimer(time.Hour)
+	defer expiry.Stop()
+
+	send := func(events ...SubscribeEvent) bool {
+		for _, event := range events {
+			conn.SetWriteDeadline(time.Now().Add(subscribeWriteTimeout))
+			if err := conn.WriteJSON(&event); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		resetExpiry(expiry, subscriptions)
+
+		var events []SubscribeEvent
+		select {
+		case request := <-requests:
+			events = handleSubscribeRequest(ctx, directory, watcher, subscriptions, changed, request)
+
+		case name := <-changed:
+			if sub, ok := subscriptions[name]; ok {
+				events = diffEntries(directory, name, sub)
+			}
+
+		case <-expiry.C:
+			for name, sub := range subscriptions {
+				events = append(events, diffEntries(directory, name, sub)...)
+			}
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(subscribeWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case <-closed:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		if !send(events...) {
+			return
+		}
+	}
+}
+
+func handleSubscribeRequest(ctx context.Context, directory soroban.Directory, watcher soroban.Watcher, subscriptions map[string]*subscription, changed chan string, request SubscribeRequest) []SubscribeEvent {
+	subscribeError := func(err *common.Error) []SubscribeEvent {
+		return []SubscribeEvent{{Event: SubscribeEventError, Name: request.Name, Code: err.Code, Error: err.Message}}
+	}
+	if len(request.Name) == 0 {
+		return subscribeError(common.InvalidArgsErr)
+	}
+
+	switch request.Action {
+	case SubscribeActionSubscribe:
+		if _, ok := subscriptions[request.Name]; ok {
+			return []SubscribeEvent{{Event: SubscribeEventSubscribed, Name: request.Name}}
+		}
+		if len(subscriptions) >= MaxSubscriptionsPerConn {
+			return subscribeError(common.TooManyWaitsErr)
+		}
+
+		info := confidential.GetConfidentialInfo(request.Name, request.PublicKey)
+		// check signature if key is confidential, subscribe is not allowed for anonymous
+		if info.Confidential {
+			args := services.DirectoryEntries{
+				Name:      request.Name,
+				PublicKey: request.PublicKey,
+				Algorithm: request.Algorithm,
+				Signature: request.Signature,
+				Timestamp: request.Timestamp,
+
+				SignatureVersion: request.SignatureVersion,
+			}
+			err := args.VerifySignature("directory.List", info)
+			if err == nil {
+				err = args.VerifyReplay(ctx, "directory.List", info)
+			}
+			if err != nil {
+				log.WithError(err).Error("Failed to verifySignature")
+				return subscribeError(common.AsError(err))
+			}
+		}
+
+		// watch before listing, changes between list & watch are not lost
+		events, cancel := watcher.Watch(request.Name)
+		sub := &subscription{
+			entries: make(map[string]time.Time),
+			cancel:  cancel,
+			done:    make(chan struct{}),
+		}
+		subscriptions[request.Name] = sub
+
+		go func(name string) {
+			for {
+				select {
+				case <-events:
+					select {
+					case changed <- name:
+					case <-sub.done:
+						return
+					}
+				case <-sub.done:
+					return
+				}
+			}
+		}(request.Name)
+
+		result := []SubscribeEvent{{Event: SubscribeEventSubscribed, Name: request.Name}}
+		return append(result, diffEntries(directory, request.Name, sub)...)
+
+	case SubscribeActionUnsubscribe:
+		if sub, ok := subscriptions[request.Name]; ok {
+			sub.cancel()
+			close(sub.done)
+			delete(subscriptions, request.Name)
+		}
+		return []SubscribeEvent{{Event: SubscribeEventUnsubscribed, Name: request.Name}}
+
+	default:
+		return subscribeError(common.InvalidArgsErr)
+	}
+}
+
+// diffEntries return events from known entries to current entries.
+// missing entries are expired if past their expiry, removed otherwise.
+func diffEntries(directory soroban.Directory, name string, sub *subscription) []SubscribeEvent {
+	entries, err := directory.Entries(name)
+	if err != nil {
+		log.WithError(err).Error("Failed to list directory")
+		return nil
+	}
+
+	var result []SubscribeEvent
+	current := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		current[entry.Value] = entry.ExpireOn
+		if _, ok := sub.entries[entry.Value]; !ok {
+			result = append(result, SubscribeEvent{Event: SubscribeEventAdd, Name: name, Entry: entry.Value})
+		}
+	}
+
+	now := time.Now()
+	for value, expireOn := range sub.entries {
+		if _, ok := current[value]; ok {
+			continue
+		}
+		event := SubscribeEventRemove
+		if !expireOn.After(now) {
+			event = SubscribeEventExpire
+		}
+		result = append(result, SubscribeEvent{Event: event, Name: name, Entry: value})
+	}
+
+	sub.entries = current
+	return result
+}
+
+// resetExpiry set timer to the soonest known entry expiry
+func resetExpiry(timer *time.Timer, subscriptions map[string]*subscription) {
+	next := time.Hour
+	now := time.Now()
+	for _, sub := range subscriptions {
+		for _, expireOn := range sub.entries {
+			// expiry is checked after expireOn, with millisecond precision
+			if delay := expireOn.Sub(now) + time.Millisecond; delay < next {
+				next = delay
+			}
+		}
+	}
+	if next < 0 {
+		next = 0
+	}
+
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(next)
+}
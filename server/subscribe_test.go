@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/internal/memory"
+	"soroban/internal/notify"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscribeHandler(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+	directory.Add("key", "a", directory.TimeToLive("normal"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), internal.SorobanDirectoryKey, directory)
+		SubscribeHandler(w, r.WithContext(ctx))
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	expect := func(want SubscribeEvent) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var got SubscribeEvent
+		if err := conn.ReadJSON(&got); err != nil {
+			t.Fatalf("ReadJSON() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("event = %+v, want %+v", got, want)
+		}
+	}
+
+	conn.WriteJSON(&SubscribeRequest{Action: SubscribeActionSubscribe, Name: "key"})
+	expect(SubscribeEvent{Event: SubscribeEventSubscribed, Name: "key"})
+	expect(SubscribeEvent{Event: SubscribeEventAdd, Name: "key", Entry: "a"})
+
+	directory.Add("key", "b", time.Second)
+	expect(SubscribeEvent{Event: SubscribeEventAdd, Name: "key", Entry: "b"})
+
+	directory.Remove("key", "a")
+	expect(SubscribeEvent{Event: SubscribeEventRemove, Name: "key", Entry: "a"})
+
+	expect(SubscribeEvent{Event: SubscribeEventExpire, Name: "key", Entry: "b"})
+
+	conn.WriteJSON(&SubscribeRequest{Action: "invalid", Name: "key"})
+	expect(SubscribeEvent{Event: SubscribeEventError, Name: "key", Code: common.CodeInvalidArgs, Error: common.InvalidArgsErr.Message})
+}
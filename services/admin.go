@@ -0,0 +1,287 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	soroban "soroban"
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/internal/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// AdminSignatureWindow is the maximum age of admin requests signatures
+	AdminSignatureWindow = 5 * time.Minute
+
+	redacted = "<redacted>"
+)
+
+// AdminArgs for admin json-rpc requests
+// Signature is required when an admin public key is configured, message is "method.Name.Timestamp".
+// Signatures are used once, replayed requests are rejected.
+type AdminArgs struct {
+	Name      string `json:",omitempty"`
+	Signature string `json:",omitempty"`
+	Timestamp int64  `json:",omitempty"`
+}
+
+// AdminFlushResponse for admin.Flush
+type AdminFlushResponse struct {
+	Name    string
+	Removed int
+}
+
+// AdminInspectResponse for admin.Inspect
+type AdminInspectResponse struct {
+	Name    string
+	Entries []soroban.Entry
+}
+
+// AdminPeer is a connected P2P peer
+type AdminPeer struct {
+	ID    string
+	Addrs []string
+}
+
+// AdminPeersResponse for admin.Peers
+type AdminPeersResponse struct {
+	Peers []AdminPeer
+}
+
+// AdminReloadResponse for admin.ReloadConfig
+type AdminReloadResponse struct {
+	Entries int
+}
+
+// Admin json-rpc service for operators.
+// Requests are allowed on the admin listener, or on any listener if signed by the admin key.
+// Every request is written to the audit log.
+type Admin struct {
+	options soroban.Options
+	audit   *log.Logger
+}
+
+func NewAdmin(options soroban.Options) *Admin {
+	audit := log.StandardLogger()
+	if len(options.Soroban.AuditLog) > 0 {
+		file, err := os.OpenFile(options.Soroban.AuditLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			log.WithError(err).WithField("Filename", options.Soroban.AuditLog).Error("Failed to open audit log")
+		} else {
+			audit = log.New()
+			audit.SetOutput(file)
+			audit.SetFormatter(&log.JSONFormatter{})
+		}
+	}
+	return &Admin{
+		options: options,
+		audit:   audit,
+	}
+}
+
+// Flush remove all values of key.
+// Removals are not propagated to other nodes.
+func (t *Admin) Flush(r *http.Request, args *AdminArgs, result *AdminFlushResponse) error {
+	err := t.authorize(r, "admin.Flush", args)
+	if err == nil {
+		*result, err = t.flush(r, args)
+	}
+	t.record(r, "admin.Flush", args, err)
+	return err
+}
+
+func (t *Admin) flush(r *http.Request, args *AdminArgs) (AdminFlushResponse, error) {
+	directory := internal.DirectoryFromContext(r.Context())
+	if directory == nil {
+		return AdminFlushResponse{}, common.InternalErr
+	}
+	if len(args.Name) == 0 {
+		return AdminFlushResponse{}, common.InvalidArgsErr
+	}
+	entries, err := directory.List(args.Name)
+	if err != nil {
+		return AdminFlushResponse{}, common.ListErr
+	}
+	var removed []string
+	if len(entries) > 0 {
+		removed, err = directory.Pop(args.Name, len(entries))
+		if err != nil {
+			return AdminFlushResponse{}, common.RemoveErr
+		}
+	}
+	return AdminFlushResponse{
+		Name:    args.Name,
+		Removed: len(removed),
+	}, nil
+}
+
+// Inspect return key entries with sequence and expiry time.
+func (t *Admin) Inspect(r *http.Request, args *AdminArgs, result *AdminInspectResponse) error {
+	err := t.authorize(r, "admin.Inspect", args)
+	if err == nil {
+		directory := internal.DirectoryFromContext(r.Context())
+		if directory == nil {
+			err = common.InternalErr
+		} else {
+			var entries []soroban.Entry
+			entries, err = directory.Entries(args.Name)
+			if err != nil {
+				err = common.ListErr
+			}
+			*result = AdminInspectResponse{
+				Name:    args.Name,
+				Entries: entries,
+			}
+		}
+	}
+	t.record(r, "admin.Inspect", args, err)
+	return err
+}
+
+// Peers return P2P peers connected to this process.
+func (t *Admin) Peers(r *http.Request, args *AdminArgs, result *AdminPeersResponse) error {
+	err := t.authorize(r, "admin.Peers", args)
+	if err == nil {
+		result.Peers = make([]AdminPeer, 0)
+		if p2P := internal.P2PFromContext(r.Context()); p2P != nil {
+			for _, info := range p2P.Peers() {
+				peer := AdminPeer{ID: info.ID.String()}
+				for _, addr := range info.Addrs {
+					peer.Addrs = append(peer.Addrs, addr.String())
+				}
+				result.Peers = append(result.Peers, peer)
+			}
+		}
+	}
+	t.record(r, "admin.Peers", args, err)
+	return err
+}
+
+// ReloadConfig reload confidential keys configuration.
+func (t *Admin) ReloadConfig(r *http.Request, args *AdminArgs, result *AdminReloadResponse) error {
+	err := t.authorize(r, "admin.ReloadConfig", args)
+	if err == nil {
+		if len(t.options.Soroban.Confidential) == 0 {
+			err = common.InvalidArgsErr
+		} else {
+			result.Entries = confidential.ConfigReload(t.options.Soroban.Confidential)
+		}
+	}
+	t.record(r, "admin.ReloadConfig", args, err)
+	return err
+}
+
+// SavePeerstore write P2P peerstore file.
+func (t *Admin) SavePeerstore(r *http.Request, args *AdminArgs, result *Response) error {
+	err := t.authorize(r, "admin.SavePeerstore", args)
+	if err == nil {
+		p2P := internal.P2PFromContext(r.Context())
+		if p2P == nil || t.options.P2P.PeerstoreFile == "-" {
+			err = common.InvalidArgsErr
+		} else if err = p2P.PersistPeerstore(r.Context(), t.options.P2P); err != nil {
+			log.WithError(err).Error("Failed to save peerstore")
+			err = common.InternalErr
+		} else {
+			result.Status = "success"
+		}
+	}
+	t.record(r, "admin.SavePeerstore", args, err)
+	return err
+}
+
+// Options return effective options, secrets are redacted.
+func (t *Admin) Options(r *http.Request, args *AdminArgs, result *soroban.Options) error {
+	err := t.authorize(r, "admin.Options", args)
+	if err == nil {
+		*result = t.options
+		if len(result.Soroban.Seed) > 0 {
+			result.Soroban.Seed = redacted
+		}
+		if len(result.P2P.Seed) > 0 {
+			result.P2P.Seed = redacted
+		}
+	}
+	t.record(r, "admin.Options", args, err)
+	return err
+}
+
+// checkAdminOptions return ErrAdminAlgorithm if admin public key is set with an unsupported algorithm
+func checkAdminOptions(options soroban.Options) error {
+	if len(options.Soroban.AdminPublicKey) == 0 {
+		return nil
+	}
+	if !slices.Contains(confidential.Algorithms, options.Soroban.AdminAlgorithm) {
+		return ErrAdminAlgorithm
+	}
+	return nil
+}
+
+// authorize check request is received on admin listener or signed by admin key.
+func (t *Admin) authorize(r *http.Request, method string, args *AdminArgs) error {
+	var listener string
+	if conn := internal.ConnInfoFromContext(r.Context()); conn != nil {
+		listener = conn.Listener
+	}
+
+	signed := len(t.options.Soroban.AdminPublicKey) > 0
+	if listener != internal.AdminListener && !signed {
+		return common.ForbiddenErr
+	}
+	if !signed {
+		return nil
+	}
+	// unconfigured entries are not checked by verifySignature
+	if len(args.Signature) == 0 || checkAdminOptions(t.options) != nil {
+		return common.SignatureErr
+	}
+
+	now := time.Now().UTC()
+	timestamp := time.Unix(0, args.Timestamp).UTC()
+	if !timeInRange(now.Add(-AdminSignatureWindow), now.Add(AdminSignatureWindow), timestamp) {
+		return common.TimestampErr
+	}
+
+	info := confidential.ConfidentialEntry{
+		Prefix:    "admin",
+		Algorithm: t.options.Soroban.AdminAlgorithm,
+		PublicKey: t.options.Soroban.AdminPublicKey,
+	}
+	message := fmt.Sprintf("%s.%s.%d", method, args.Name, args.Timestamp)
+	err := verifySignature(info, info.PublicKey, message, info.Algorithm, args.Signature)
+	if err != nil {
+		return err
+	}
+
+	hash := signatureHash(info.PublicKey, info.Algorithm, message)
+	if !useSignature(r.Context(), hash, timestamp.Add(AdminSignatureWindow)) {
+		return common.ReplayErr
+	}
+	return nil
+}
+
+// record write admin request to audit log
+func (t *Admin) record(r *http.Request, method string, args *AdminArgs, err error) {
+	var listener string
+	if conn := internal.ConnInfoFromContext(r.Context()); conn != nil {
+		listener = conn.Listener
+	}
+	entry := t.audit.WithFields(log.Fields{
+		"audit":    true,
+		"method":   method,
+		"name":     args.Name,
+		"listener": listener,
+		"remote":   r.RemoteAddr,
+		"signed":   len(args.Signature) > 0,
+	})
+	if err != nil {
+		entry.WithError(err).Warning("Admin request rejected")
+		return
+	}
+	entry.Info("Admin request")
+}
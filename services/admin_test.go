@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	soroban "soroban"
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/internal/memory"
+
+	"golang.org/x/crypto/nacl/sign"
+)
+
+func TestAdmin_Flush(t *testing.T) {
+	directory := memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	admin := NewAdmin(soroban.DefaultOptions)
+
+	tests := []struct {
+		name     string
+		listener string
+		want     error
+		removed  int
+	}{
+		{"public", "IPv4", common.ForbiddenErr, 0},
+		{"admin", internal.AdminListener, nil, 2},
+		{"empty", internal.AdminListener, nil, 0},
+	}
+	directory.Add("key", "a", directory.TimeToLive("normal"))
+	directory.Add("key", "b", directory.TimeToLive("normal"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), internal.SorobanDirectoryKey, directory)
+			ctx = context.WithValue(ctx, internal.SorobanConnKey, &internal.ConnInfo{Listener: tt.listener})
+			r := httptest.NewRequest("POST", "/rpc", nil).WithContext(ctx)
+
+			var result AdminFlushResponse
+			err := admin.Flush(r, &AdminArgs{Name: "key"}, &result)
+			if err != tt.want {
+				t.Fatalf("Flush() error = %v, want %v", err, tt.want)
+			}
+			if result.Removed != tt.removed {
+				t.Errorf("Flush() removed = %d, want %d", result.Removed, tt.removed)
+			}
+		})
+	}
+}
+
+func TestAdmin_SignedReplay(t *testing.T) {
+	publicKey, privateKey, err := sign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := soroban.DefaultOptions
+	options.Soroban.AdminPublicKey = hex.EncodeToString(publicKey[:])
+	options.Soroban.AdminAlgorithm = confidential.AlgorithmNacl
+	admin := NewAdmin(options)
+
+	timestamp := time.Now().UnixNano()
+	message := fmt.Sprintf("admin.Peers..%d", timestamp)
+	signed := AdminArgs{
+		Signature: hex.EncodeToString(sign.Sign(nil, []byte(message), privateKey)[:sign.Overhead]),
+		Timestamp: timestamp,
+	}
+
+	tests := []struct {
+		name     string
+		listener string
+		args     AdminArgs
+		want     error
+	}{
+		{"unsigned", "IPv4", AdminArgs{Timestamp: timestamp}, common.SignatureErr},
+		{"signed", "IPv4", signed, nil},
+		{"replayed", "IPv4", signed, common.ReplayErr},
+		{"replayed-admin", internal.AdminListener, signed, common.ReplayErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), internal.SorobanConnKey, &internal.ConnInfo{Listener: tt.listener})
+			r := httptest.NewRequest("POST", "/rpc", nil).WithContext(ctx)
+
+			var result AdminPeersResponse
+			if err := admin.Peers(r, &tt.args, &result); err != tt.want {
+				t.Errorf("Peers() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmin_InvalidAlgorithm(t *testing.T) {
+	options := soroban.DefaultOptions
+	options.Soroban.AdminPublicKey = "deadbeef"
+	options.Soroban.AdminAlgorithm = ""
+	if err := checkAdminOptions(options); err != ErrAdminAlgorithm {
+		t.Errorf("checkAdminOptions() error = %v, want %v", err, ErrAdminAlgorithm)
+	}
+
+	admin := NewAdmin(options)
+	ctx := context.WithValue(context.Background(), internal.SorobanConnKey, &internal.ConnInfo{Listener: "IPv4"})
+	r := httptest.NewRequest("POST", "/rpc", nil).WithContext(ctx)
+	var result soroban.Options
+	if err := admin.Options(r, &AdminArgs{Timestamp: time.Now().UnixNano()}, &result); err != common.SignatureErr {
+		t.Errorf("Options() error = %v, want %v", err, common.SignatureErr)
+	}
+}
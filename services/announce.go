@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"soroban/confidential"
 	"soroban/internal"
 
 	log "github.com/sirupsen/logrus"
@@ -58,6 +59,15 @@ func StartAnnounce(ctx context.Context, announceKey string, version string, node
 					Entry: string(data),
 					Mode:  "normal",
 				}
+				// mint proof of work if required for announce key
+				if difficulty := confidential.GetConfidentialInfo(announceKey, "").Difficulty; difficulty > 0 {
+					directoryEntry.Timestamp = time.Now().UnixNano()
+					directoryEntry.Nonce, err = confidential.MintStamp(ctx, announceKey, directoryEntry.Entry, directoryEntry.Timestamp, difficulty)
+					if err != nil {
+						log.WithError(err).Error("failed to mint announce stamp")
+						break
+					}
+				}
 
 				req, err := http.NewRequestWithContext(ctx, "POST", "", nil)
 				if err != nil {
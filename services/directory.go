@@ -1,8 +1,9 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	soroban "soroban"
 	"soroban/confidential"
 	"soroban/internal"
+	"soroban/internal/common"
 	"soroban/ipc"
 	"soroban/p2p"
 
@@ -18,19 +20,31 @@ import (
 )
 
 // DirectoryEntries for json-rpc request
+// Ordered list entries in insertion order, by pages of PageSize entries after Cursor.
+// Since return only entries inserted after the Since cursor.
+// SignatureVersion select the signed message, v1 if not set.
 type DirectoryEntries struct {
 	Name      string
 	Limit     int
+	Ordered   bool   `json:",omitempty"`
+	Cursor    string `json:",omitempty"`
+	PageSize  int    `json:",omitempty"`
+	Since     string `json:",omitempty"`
 	PublicKey string
 	Algorithm string
 	Signature string
 	Timestamp int64
+	// SignatureVersion is confidential.SignatureV1 or confidential.SignatureV2
+	SignatureVersion int `json:",omitempty"`
 }
 
 // DirectoryEntriesResponse for json-rpc response
+// Cursor is the position of the last returned entry for ordered listing.
 type DirectoryEntriesResponse struct {
 	Name    string
 	Entries []string
+	Cursor  string `json:",omitempty"`
+	More    bool   `json:",omitempty"`
 }
 
 // DirectoryEntry for json-rpc request
@@ -42,6 +56,10 @@ type DirectoryEntry struct {
 	Algorithm string
 	Signature string
 	Timestamp int64
+	// Nonce is the proof of work stamp nonce, required when key prefix has a difficulty
+	Nonce string `json:",omitempty"`
+	// SignatureVersion is confidential.SignatureV1 or confidential.SignatureV2
+	SignatureVersion int `json:",omitempty"`
 }
 
 // Directory struct for json-rpc
@@ -51,23 +69,42 @@ func (t *Directory) List(r *http.Request, args *DirectoryEntries, result *Direct
 	directory := internal.DirectoryFromContext(r.Context())
 	if directory == nil {
 		log.Error("Directory not found")
-		return nil
+		return common.InternalErr
+	}
+	err := rateLimit(r.Context(), "directory.List", args.Name)
+	if err != nil {
+		return err
 	}
 
 	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
 	// check signature if key is confidential, list is not allowed for anonymous
 	if info.Confidential {
-		err := args.VerifySignature(info)
+		err := args.VerifySignature("directory.List", info)
+		if err == nil {
+			err = args.VerifyReplay(r.Context(), "directory.List", info)
+		}
 		if err != nil {
 			log.WithError(err).Error("Failed to verifySignature")
-			return nil
+			return err
 		}
 	}
 
+	if args.ordered() {
+		response, err := listOrdered(directory, args)
+		if err != nil {
+			log.WithError(err).Error("Failed to list directory")
+			return err
+		}
+		log.Tracef("List: %s (%d)", args.Name, len(response.Entries))
+
+		*result = response
+		return nil
+	}
+
 	entries, err := directory.List(args.Name)
 	if err != nil {
 		log.WithError(err).Error("Failed to list directory")
-		return nil
+		return err
 	}
 
 	if args.Limit > 0 && args.Limit < len(entries) {
@@ -89,9 +126,65 @@ func (t *Directory) List(r *http.Request, args *DirectoryEntries, result *Direct
 	return nil
 }
 
+func (p *DirectoryEntries) ordered() bool {
+	return p.Ordered || len(p.Cursor) > 0 || len(p.Since) > 0 || p.PageSize > 0
+}
+
+// listOrdered return entries after cursor in insertion order
+func listOrdered(directory soroban.Directory, args *DirectoryEntries) (DirectoryEntriesResponse, error) {
+	cursor := args.Cursor
+	if len(args.Since) > 0 {
+		cursor = args.Since
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return DirectoryEntriesResponse{}, err
+	}
+
+	entries, err := directory.Entries(args.Name)
+	if err != nil {
+		return DirectoryEntriesResponse{}, err
+	}
+
+	result := DirectoryEntriesResponse{
+		Name:    args.Name,
+		Entries: make([]string, 0),
+		Cursor:  cursor,
+	}
+	for _, entry := range entries {
+		if entry.Sequence <= after {
+			continue
+		}
+		if args.PageSize > 0 && len(result.Entries) >= args.PageSize {
+			result.More = true
+			break
+		}
+		result.Entries = append(result.Entries, entry.Value)
+		result.Cursor = encodeCursor(entry.Sequence)
+	}
+	return result, nil
+}
+
+func encodeCursor(sequence uint64) string {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], sequence)
+	return base64.RawURLEncoding.EncodeToString(data[:])
+}
+
+func decodeCursor(cursor string) (uint64, error) {
+	if len(cursor) == 0 {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) != 8 {
+		return 0, common.InvalidArgsErr
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
 func addToDirectory(directory soroban.Directory, args *DirectoryEntry) error {
 	if args == nil {
-		return errors.New("invalid args")
+		return common.InvalidArgsErr
 	}
 	return directory.Add(args.Name, args.Entry, directory.TimeToLive(args.Mode))
 }
@@ -101,31 +194,40 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 	directory := internal.DirectoryFromContext(ctx)
 	if directory == nil {
 		log.Error("Directory not found")
-		return nil
+		return common.InternalErr
+	}
+	err := rateLimit(ctx, "directory.Add", args.Name)
+	if err != nil {
+		return err
 	}
 
 	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
 	// check signature if key is readonly, add is not allowed for anonymous
 	if info.ReadOnly {
-		err := args.VerifySignature(info)
+		err := args.VerifySignature("directory.Add", info)
+		if err == nil {
+			err = args.VerifyReplay(ctx, "directory.Add", info)
+		}
 		if err != nil {
 			log.WithError(err).Error("Failed to verifySignature")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+			return err
+		}
+	}
+	// check proof of work if key prefix requires it
+	if info.Difficulty > 0 {
+		err := args.VerifyStamp(info)
+		if err != nil {
+			log.WithError(err).Debug("Failed to VerifyStamp")
+			return err
 		}
 	}
 
 	log.Debugf("Add: %s %s", args.Name, args.Entry)
 
-	err := addToDirectory(directory, args)
+	err = addToDirectory(directory, args)
 	if err != nil {
 		log.WithError(err).Error("Failed to Add entry")
-		*result = Response{
-			Status: "error",
-		}
-		return nil
+		return err
 	}
 
 	if client := internal.IPCFromContext(ctx); client != nil {
@@ -133,19 +235,13 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 		message, err := p2p.NewMessage("Directory.Add", &args)
 		if err != nil {
 			log.WithError(err).Error("failed to marshal p2P message.")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+			return common.InternalErr
 		}
 
 		data, err := json.Marshal(message)
 		if err != nil {
 			log.WithError(err).Error("failed to marshal p2p message")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+			return common.InternalErr
 		}
 		resp, err := client.Request(ipc.Message{
 			Type:    ipc.MessageTypeIPC,
@@ -153,10 +249,7 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 		}, "down")
 		if err != nil {
 			log.WithError(err).Error("IPC requext failed")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+			return common.InternalErr
 		}
 		if resp.Message != "success" {
 			log.WithField("Message", resp.Message).Warning("IPC Message failed")
@@ -164,28 +257,27 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 		log.WithField("Message", resp.Message).Debug("IPC Message sent")
 	}
 
-	if p2P := internal.P2PFromContext(ctx); p2P != nil {
-
-		err := p2P.PublishJson(ctx, "Directory.Add", args)
-		if err != nil {
-			// non fatal error
-			log.Printf("p2P - Failed to PublishJson. %s\n", err)
-		}
-
-		*result = Response{
-			Status: "success",
-		}
-	} else {
+	p2P := internal.P2PFromContext(ctx)
+	if p2P == nil {
 		log.Println("p2P - P2P not found")
-		return nil
+		return common.InternalErr
+	}
+
+	err = p2P.PublishJson(ctx, "Directory.Add", args)
+	if err != nil {
+		// non fatal error
+		log.Printf("p2P - Failed to PublishJson. %s\n", err)
 	}
 
+	*result = Response{
+		Status: "success",
+	}
 	return nil
 }
 
 func removeFromDirectory(directory soroban.Directory, args *DirectoryEntry) error {
 	if args == nil {
-		return errors.New("invalid args")
+		return common.InvalidArgsErr
 	}
 	return directory.Remove(args.Name, args.Entry)
 }
@@ -195,32 +287,38 @@ func (t *Directory) Remove(r *http.Request, args *DirectoryEntry, result *Respon
 	directory := internal.DirectoryFromContext(ctx)
 	if directory == nil {
 		log.Error("Directory not found")
-		return nil
+		return common.InternalErr
+	}
+	err := rateLimit(ctx, "directory.Remove", args.Name)
+	if err != nil {
+		return err
 	}
 
 	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
 	// check signature if key is readonly, remove is not allowed for anonymous
 	if info.ReadOnly {
-		err := args.VerifySignature(info)
+		err := args.VerifySignature("directory.Remove", info)
+		if err == nil {
+			err = args.VerifyReplay(ctx, "directory.Remove", info)
+		}
 		if err != nil {
 			log.WithError(err).Error("Failed to verifySignature")
-			return nil
+			return err
 		}
 	}
 
 	p2P := internal.P2PFromContext(ctx)
 	if p2P == nil {
 		log.Println("p2P - P2P not found")
-		return nil
+		return common.InternalErr
 	}
 
 	log.Debugf("Remove: %s %s", args.Name, args.Entry)
 
-	status := "success"
-	err := removeFromDirectory(directory, args)
+	err = removeFromDirectory(directory, args)
 	if err != nil {
-		status = "error"
 		log.WithError(err).Error("Failed to Remove directory")
+		return err
 	}
 
 	err = p2P.PublishJson(ctx, "Directory.Remove", args)
@@ -230,7 +328,77 @@ func (t *Directory) Remove(r *http.Request, args *DirectoryEntry, result *Respon
 	}
 
 	*result = Response{
-		Status: status,
+		Status: "success",
+	}
+	return nil
+}
+
+func popFromDirectory(directory soroban.Directory, args *DirectoryEntries) ([]string, error) {
+	if args == nil {
+		return nil, common.InvalidArgsErr
+	}
+	count := args.Limit
+	if count < 1 {
+		count = 1
+	}
+	return directory.Pop(args.Name, count)
+}
+
+// Pop atomically remove and return up to Limit values (default 1), in insertion order.
+// Removals are propagated like Remove.
+func (t *Directory) Pop(r *http.Request, args *DirectoryEntries, result *DirectoryEntriesResponse) error {
+	ctx := r.Context()
+	directory := internal.DirectoryFromContext(ctx)
+	if directory == nil {
+		log.Error("Directory not found")
+		return common.InternalErr
+	}
+	err := rateLimit(ctx, "directory.Pop", args.Name)
+	if err != nil {
+		return err
+	}
+
+	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
+	// check signature if key is confidential or readonly, pop is not allowed for anonymous
+	if info.Confidential || info.ReadOnly {
+		err := args.VerifySignature("directory.Pop", info)
+		if err == nil {
+			err = args.VerifyReplay(ctx, "directory.Pop", info)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to verifySignature")
+			return err
+		}
+	}
+
+	p2P := internal.P2PFromContext(ctx)
+	if p2P == nil {
+		log.Println("p2P - P2P not found")
+		return common.InternalErr
+	}
+
+	entries, err := popFromDirectory(directory, args)
+	if err != nil {
+		log.WithError(err).Error("Failed to Pop directory")
+		return err
+	}
+
+	log.Debugf("Pop: %s (%d)", args.Name, len(entries))
+
+	for _, entry := range entries {
+		err = p2P.PublishJson(ctx, "Directory.Remove", DirectoryEntry{
+			Name:  args.Name,
+			Entry: entry,
+		})
+		if err != nil {
+			// non fatal error
+			log.Printf("p2P - Failed to PublishJson. %s\n", err)
+		}
+	}
+
+	*result = DirectoryEntriesResponse{
+		Name:    args.Name,
+		Entries: entries,
 	}
 	return nil
 }
@@ -239,7 +407,8 @@ func timeInRange(start, end, check time.Time) bool {
 	return check.After(start) && check.Before(end)
 }
 
-func (p *DirectoryEntries) VerifySignature(info confidential.ConfidentialEntry) error {
+// VerifySignature check the signature of operation (ie directory.List) for confidential keys
+func (p *DirectoryEntries) VerifySignature(operation string, info confidential.ConfidentialEntry) error {
 	if len(info.Prefix) == 0 || len(info.Algorithm) == 0 || len(info.PublicKey) == 0 {
 		return nil
 	}
@@ -247,35 +416,95 @@ func (p *DirectoryEntries) VerifySignature(info confidential.ConfidentialEntry)
 	now := time.Now().UTC()
 	timestamp := time.Unix(0, p.Timestamp).UTC()
 	log.WithField("Timestamp", timestamp).Warning("VerifySignature")
-	delta := 24 * time.Hour
+	delta := info.SignatureWindow()
 
 	if p.PublicKey != info.PublicKey {
-		return errors.New("PublicKey not allowed")
+		return common.ForbiddenErr
 	}
 
 	if !timeInRange(now.Add(-delta), now.Add(delta), timestamp) {
-		return errors.New("timestamp not in time range")
+		return common.TimestampErr
+	}
+
+	message, err := p.SignedMessage(operation, confidential.Domain())
+	if err != nil {
+		return err
 	}
+	return verifySignature(info, p.PublicKey, message, p.Algorithm, p.Signature)
+}
+
+// SignatureMessage return the v1 signed message of List & Wait requests
+func (p *DirectoryEntries) SignatureMessage() string {
+	return fmt.Sprintf("%v.%v", p.Name, p.Timestamp)
+}
 
-	message := fmt.Sprintf("%v.%v", p.Name, p.Timestamp)
-	return confidential.VerifySignature(info, p.PublicKey, message, p.Algorithm, p.Signature)
+// PopSignatureMessage return the v1 signed message of Pop requests,
+// List signatures can't be used to remove entries.
+func (p *DirectoryEntries) PopSignatureMessage() string {
+	return fmt.Sprintf("pop.%v.%v", p.Name, p.Timestamp)
 }
 
-func (p *DirectoryEntry) VerifySignature(info confidential.ConfidentialEntry) error {
+// SignedMessage return the signed message of operation for SignatureVersion
+func (p *DirectoryEntries) SignedMessage(operation, domain string) (string, error) {
+	switch p.SignatureVersion {
+	case 0, confidential.SignatureV1:
+		if operation == "directory.Pop" {
+			return p.PopSignatureMessage(), nil
+		}
+		return p.SignatureMessage(), nil
+	case confidential.SignatureV2:
+		return confidential.CanonicalMessage(operation, domain, p.Name, "", "", p.Timestamp), nil
+	default:
+		return "", common.SignatureErr
+	}
+}
+
+// VerifySignature check the signature of operation (ie directory.Add) for readonly keys
+func (p *DirectoryEntry) VerifySignature(operation string, info confidential.ConfidentialEntry) error {
 	if len(info.Prefix) == 0 || len(info.Algorithm) == 0 || len(info.PublicKey) == 0 {
 		return nil
 	}
 
 	if p.PublicKey != info.PublicKey {
-		return errors.New("PublicKey not allowed")
+		return common.ForbiddenErr
 	}
 
 	now := time.Now().UTC()
 	timestamp := time.Unix(0, p.Timestamp).UTC()
-	delta := 24 * time.Hour
+	delta := info.SignatureWindow()
 	if !timeInRange(now.Add(-delta), now.Add(delta), timestamp) {
-		return errors.New("timestamp not in time range")
+		return common.TimestampErr
+	}
+	message, err := p.SignedMessage(operation, confidential.Domain())
+	if err != nil {
+		return err
+	}
+	return verifySignature(info, p.PublicKey, message, p.Algorithm, p.Signature)
+}
+
+// SignatureMessage return the v1 signed message of Add & Remove requests
+func (p *DirectoryEntry) SignatureMessage() string {
+	return fmt.Sprintf("%s.%d.%s", p.Name, p.Timestamp, p.Entry)
+}
+
+// SignedMessage return the signed message of operation for SignatureVersion
+func (p *DirectoryEntry) SignedMessage(operation, domain string) (string, error) {
+	switch p.SignatureVersion {
+	case 0, confidential.SignatureV1:
+		return p.SignatureMessage(), nil
+	case confidential.SignatureV2:
+		return confidential.CanonicalMessage(operation, domain, p.Name, p.Entry, p.Mode, p.Timestamp), nil
+	default:
+		return "", common.SignatureErr
+	}
+}
+
+// verifySignature return SignatureErr if signature is not valid
+func verifySignature(info confidential.ConfidentialEntry, publicKey, message, algorithm, signature string) error {
+	err := confidential.VerifySignature(info, publicKey, message, algorithm, signature)
+	if err != nil {
+		log.WithError(err).Debug("Invalid signature")
+		return common.SignatureErr
 	}
-	message := fmt.Sprintf("%s.%d.%s", p.Name, p.Timestamp, p.Entry)
-	return confidential.VerifySignature(info, p.PublicKey, message, p.Algorithm, p.Signature)
+	return nil
 }
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"soroban/internal/common"
+	"soroban/internal/memory"
+)
+
+func Test_listOrdered(t *testing.T) {
+	directory := memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	for _, value := range []string{"a", "b", "c", "d", "e"} {
+		directory.Add("key", value, directory.TimeToLive("normal"))
+	}
+
+	var got []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		response, err := listOrdered(directory, &DirectoryEntries{Name: "key", Cursor: cursor, PageSize: 2})
+		if err != nil {
+			t.Fatalf("listOrdered() error = %v", err)
+		}
+		got = append(got, response.Entries...)
+		cursor = response.Cursor
+		if !response.More {
+			break
+		}
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listOrdered() = %v, want %v", got, want)
+	}
+
+	// only new values since last cursor
+	directory.Add("key", "f", directory.TimeToLive("normal"))
+	response, _ := listOrdered(directory, &DirectoryEntries{Name: "key", Since: cursor})
+	if want := []string{"f"}; !reflect.DeepEqual(response.Entries, want) {
+		t.Errorf("listOrdered() since = %v, want %v", response.Entries, want)
+	}
+
+	if _, err := listOrdered(directory, &DirectoryEntries{Name: "key", Cursor: "invalid!"}); err != common.InvalidArgsErr {
+		t.Errorf("listOrdered() error = %v, want %v", err, common.InvalidArgsErr)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"soroban/confidential"
+	"soroban/internal/common"
+)
+
+const (
+	OpenRPCVersion = "1.2.6"
+	DiscoverMethod = "rpc.discover"
+)
+
+// OpenRPC document
+type OpenRPC struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+	// Modes are TimeToLive modes with duration in seconds
+	Modes map[string]int64 `json:"x-soroban-modes"`
+	// Algorithms are the supported signature algorithms
+	Algorithms []string `json:"x-soroban-algorithms"`
+}
+
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name           string              `json:"name"`
+	ParamStructure string              `json:"paramStructure"`
+	Params         []OpenRPCDescriptor `json:"params"`
+	Result         OpenRPCDescriptor   `json:"result"`
+}
+
+type OpenRPCDescriptor struct {
+	Name   string `json:"name"`
+	Schema Schema `json:"schema"`
+}
+
+type OpenRPCComponents struct {
+	Schemas map[string]Schema `json:"schemas"`
+}
+
+// Schema is a JSON schema
+type Schema map[string]interface{}
+
+// DiscoverArgs for json-rpc request, rpc.discover has no params
+type DiscoverArgs struct{}
+
+// Discover describe registered json-rpc services
+type Discover struct {
+	version  string
+	services []NamedService
+}
+
+func NewDiscover(version string, services []NamedService) *Discover {
+	return &Discover{
+		version:  version,
+		services: services,
+	}
+}
+
+// Discover return the OpenRPC document of registered services.
+func (t *Discover) Discover(r *http.Request, args *DiscoverArgs, result *OpenRPC) error {
+	*result = t.Document()
+	return nil
+}
+
+// Document build the OpenRPC document from registered services methods.
+func (t *Discover) Document() OpenRPC {
+	result := OpenRPC{
+		OpenRPC: OpenRPCVersion,
+		Info: OpenRPCInfo{
+			Title:   "Soroban",
+			Version: t.version,
+		},
+		Components: OpenRPCComponents{
+			Schemas: make(map[string]Schema),
+		},
+		Modes:      make(map[string]int64),
+		Algorithms: confidential.Algorithms,
+	}
+	for _, mode := range common.TimeToLiveModes {
+		result.Modes[mode] = int64(common.TimeToLive(mode) / time.Second)
+	}
+
+	services := append([]NamedService{}, t.services...)
+	services = append(services, NamedService{"rpc", t})
+	for _, ns := range services {
+		serviceType := reflect.TypeOf(ns.Service)
+		for i := 0; i < serviceType.NumMethod(); i++ {
+			method := serviceType.Method(i)
+			args, reply, ok := rpcMethodTypes(method.Type)
+			if !ok {
+				continue
+			}
+
+			name := ns.Name + "." + method.Name
+			if name == "rpc.Discover" {
+				name = DiscoverMethod
+			}
+			result.Methods = append(result.Methods, OpenRPCMethod{
+				Name:           name,
+				ParamStructure: "by-position",
+				Params: []OpenRPCDescriptor{
+					{Name: "args", Schema: schemaRef(args, result.Components.Schemas)},
+				},
+				Result: OpenRPCDescriptor{
+					Name:   "result",
+					Schema: schemaRef(reply, result.Components.Schemas),
+				},
+			})
+		}
+	}
+	return result
+}
+
+// rpcMethodTypes return args & reply types of func(r *http.Request, args *A, reply *R) error
+func rpcMethodTypes(methodType reflect.Type) (reflect.Type, reflect.Type, bool) {
+	if methodType.NumIn() != 4 || methodType.NumOut() != 1 {
+		return nil, nil, false
+	}
+	if methodType.In(1) != reflect.TypeOf((*http.Request)(nil)) {
+		return nil, nil, false
+	}
+	if methodType.In(2).Kind() != reflect.Ptr || methodType.In(3).Kind() != reflect.Ptr {
+		return nil, nil, false
+	}
+	if methodType.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		return nil, nil, false
+	}
+	return methodType.In(2).Elem(), methodType.In(3).Elem(), true
+}
+
+// schemaRef add named struct schemas to components and return a reference
+func schemaRef(t reflect.Type, schemas map[string]Schema) Schema {
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) || len(t.Name()) == 0 {
+		return typeSchema(t, schemas)
+	}
+	if _, ok := schemas[t.Name()]; !ok {
+		// placeholder for recursive types
+		schemas[t.Name()] = Schema{}
+		schemas[t.Name()] = typeSchema(t, schemas)
+	}
+	return Schema{"$ref": "#/components/schemas/" + t.Name()}
+}
+
+func typeSchema(t reflect.Type, schemas map[string]Schema) Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaRef(t.Elem(), schemas)
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return Schema{"type": "string", "format": "date-time"}
+		}
+		properties := make(map[string]Schema)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if len(tag) > 0 {
+				name = tag
+			}
+			properties[name] = fieldSchema(name, field.Type, schemas)
+		}
+		return Schema{"type": "object", "properties": properties}
+	default:
+		return Schema{}
+	}
+}
+
+// fieldSchema add known values of Mode & Algorithm fields
+func fieldSchema(name string, t reflect.Type, schemas map[string]Schema) Schema {
+	result := schemaRef(t, schemas)
+	switch name {
+	case "Mode":
+		result["enum"] = common.TimeToLiveModes
+	case "Algorithm":
+		result["enum"] = confidential.Algorithms
+	case "SignatureVersion":
+		result["enum"] = []int{confidential.SignatureV1, confidential.SignatureV2}
+	}
+	return result
+}
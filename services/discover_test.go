@@ -0,0 +1,40 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"soroban/internal/common"
+)
+
+func TestDiscover_Document(t *testing.T) {
+	document := NewDiscover("test", []NamedService{{"directory", new(Directory)}}).Document()
+
+	methods := make(map[string]OpenRPCMethod)
+	for _, method := range document.Methods {
+		methods[method.Name] = method
+	}
+	for _, name := range []string{"directory.List", "directory.Add", "directory.Remove", "directory.Pop", "directory.Wait", DiscoverMethod} {
+		if _, ok := methods[name]; !ok {
+			t.Errorf("Document() missing method %s", name)
+		}
+	}
+	if got := methods["directory.List"].Params[0].Schema["$ref"]; got != "#/components/schemas/DirectoryEntries" {
+		t.Errorf("directory.List params = %v", got)
+	}
+
+	entry, ok := document.Components.Schemas["DirectoryEntry"]
+	if !ok {
+		t.Fatal("Document() missing DirectoryEntry schema")
+	}
+	mode := entry["properties"].(map[string]Schema)["Mode"]
+	if !reflect.DeepEqual(mode["enum"], common.TimeToLiveModes) {
+		t.Errorf("Mode enum = %v, want %v", mode["enum"], common.TimeToLiveModes)
+	}
+	if _, ok := document.Components.Schemas["DirectoryEntries"]; !ok {
+		t.Error("Document() missing DirectoryEntries schema")
+	}
+	if document.Modes["short"] != 60 {
+		t.Errorf("short mode = %d", document.Modes["short"])
+	}
+}
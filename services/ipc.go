@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	soroban "soroban"
@@ -52,17 +51,7 @@ func ipcHandler(ctx context.Context, directory soroban.Directory, message ipc.Me
 			}, nil
 		}
 
-		switch p2pMessage.Context {
-		case "Directory.Add":
-			err = addToDirectory(directory, &args)
-
-		case "Directory.Remove":
-			err = removeFromDirectory(directory, &args)
-
-		default:
-			err = errors.New("unknown p2p message context")
-
-		}
+		err = applyPeerMessage(directory, p2pMessage.Context, &args)
 		if err != nil {
 			log.WithError(err).Error("failed to process message.")
 			return ipc.Message{
@@ -75,6 +64,9 @@ func ipcHandler(ctx context.Context, directory soroban.Directory, message ipc.Me
 			Type:    message.Type,
 			Message: "success",
 		}, nil
+	case ipc.MessageTypeReplay:
+		return replayHandler(message), nil
+
 	default:
 		// NOOP
 		return ipc.Message{
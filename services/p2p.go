@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -14,6 +15,11 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	errUnknownContext = errors.New("unknown p2p message context")
+	errPeerMessage    = errors.New("invalid peer message")
+)
+
 func StartP2PDirectory(ctx context.Context, options soroban.Options, ready chan struct{}) {
 	if len(options.P2P.Bootstrap) == 0 {
 		log.Error("Invalid bootstrap")
@@ -100,13 +106,7 @@ func StartP2PDirectory(ctx context.Context, options soroban.Options, ready chan
 					continue
 				}
 
-				switch message.Context {
-				case "Directory.Add":
-					err = addToDirectory(directory, &args)
-
-				case "Directory.Remove":
-					err = removeFromDirectory(directory, &args)
-				}
+				err = applyPeerMessage(directory, message.Context, &args)
 				if err != nil {
 					log.WithError(err).Error("failed to process message.")
 					continue
@@ -146,3 +146,26 @@ func StartP2PDirectory(ctx context.Context, options soroban.Options, ready chan
 		}
 	}
 }
+
+// applyPeerMessage add or remove entry received from peers or IPC children.
+// Malformed messages are dropped, panics are recovered.
+func applyPeerMessage(directory soroban.Directory, messageContext string, args *DirectoryEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("Panic", r).Error("Failed to apply peer message")
+			err = errPeerMessage
+		}
+	}()
+
+	rememberSignature(messageContext, args)
+	switch messageContext {
+	case "Directory.Add":
+		return addToDirectory(directory, args)
+
+	case "Directory.Remove":
+		return removeFromDirectory(directory, args)
+
+	default:
+		return errUnknownContext
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+
+	"soroban/internal"
+	"soroban/internal/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rateLimit consume a token from method & key bucket of connection listener & address.
+// Requests are not limited without rate limiter in context.
+func rateLimit(ctx context.Context, method, key string) error {
+	limiter := internal.RateLimiterFromContext(ctx)
+	if limiter == nil {
+		return nil
+	}
+	var listener, address string
+	if conn := internal.ConnInfoFromContext(ctx); conn != nil {
+		listener = conn.Listener
+		address = conn.Address
+	}
+	if !limiter.Allow(method, listener, address, key) {
+		log.WithField("method", method).WithField("listener", listener).Debug("Rate limited")
+		return common.RateLimitErr
+	}
+	return nil
+}
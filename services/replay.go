@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/ipc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	replayAccepted = "success"
+	replayRejected = "replayed"
+)
+
+var usedSignatures = newSeenCache(MaxSeenCacheSize)
+
+// replayRequest is the IPC payload of signatures checked by IPC children
+type replayRequest struct {
+	Hash   string
+	Expire int64
+}
+
+// signatureHash return the replay cache key of a signed request.
+// Key is the signed message, signatures encodings (ie hex case, DER) are not part of the key.
+func signatureHash(publicKey, algorithm, message string) [sha256.Size]byte {
+	var buf []byte
+	for _, field := range []string{publicKey, algorithm} {
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+	buf = append(buf, message...)
+	return sha256.Sum256(buf)
+}
+
+// VerifyReplay check signed List, Pop & Wait requests are used once within the prefix signature window.
+func (p *DirectoryEntries) VerifyReplay(ctx context.Context, operation string, info confidential.ConfidentialEntry) error {
+	message, err := p.SignedMessage(operation, confidential.Domain())
+	if err != nil {
+		return err
+	}
+	return verifyReplay(ctx, info, p.PublicKey, p.Algorithm, message, p.Timestamp)
+}
+
+// VerifyReplay check signed Add & Remove requests are used once within the prefix signature window.
+func (p *DirectoryEntry) VerifyReplay(ctx context.Context, operation string, info confidential.ConfidentialEntry) error {
+	message, err := p.SignedMessage(operation, confidential.Domain())
+	if err != nil {
+		return err
+	}
+	return verifyReplay(ctx, info, p.PublicKey, p.Algorithm, message, p.Timestamp)
+}
+
+func verifyReplay(ctx context.Context, info confidential.ConfidentialEntry, publicKey, algorithm, message string, timestamp int64) error {
+	if len(info.Prefix) == 0 || len(info.Algorithm) == 0 || len(info.PublicKey) == 0 {
+		return nil
+	}
+	hash := signatureHash(publicKey, algorithm, message)
+	expire := time.Unix(0, timestamp).Add(info.SignatureWindow())
+	if !useSignature(ctx, hash, expire) {
+		return common.ReplayErr
+	}
+	return nil
+}
+
+// useSignature return false if signature was already used.
+// IPC children check signatures with the IPC server, the replay cache is shared by all processes.
+func useSignature(ctx context.Context, hash [sha256.Size]byte, expire time.Time) bool {
+	if client := internal.IPCFromContext(ctx); client != nil && client.Mode() == "child" {
+		payload, err := json.Marshal(replayRequest{
+			Hash:   hex.EncodeToString(hash[:]),
+			Expire: expire.UnixNano(),
+		})
+		if err == nil {
+			var resp ipc.Message
+			resp, err = client.Request(ipc.Message{
+				Type:    ipc.MessageTypeReplay,
+				Payload: string(payload),
+			}, "up")
+			if err == nil {
+				return resp.Message == replayAccepted
+			}
+		}
+		log.WithError(err).Warning("Failed to check signature with IPC server")
+	}
+	return usedSignatures.use(hash, expire)
+}
+
+// rememberSignature add signed entries received from peers to the replay cache,
+// requests replayed to this node are rejected.
+// Only valid signatures of confidential & readonly keys are remembered, until the signature window ends.
+func rememberSignature(messageContext string, args *DirectoryEntry) {
+	if len(args.Signature) == 0 {
+		return
+	}
+	var operation string
+	switch messageContext {
+	case "Directory.Add":
+		operation = "directory.Add"
+	case "Directory.Remove":
+		operation = "directory.Remove"
+	default:
+		return
+	}
+
+	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
+	if !info.Confidential && !info.ReadOnly {
+		return
+	}
+	if args.VerifySignature(operation, info) != nil {
+		return
+	}
+
+	message, err := args.SignedMessage(operation, confidential.Domain())
+	if err != nil {
+		return
+	}
+	expire := time.Unix(0, args.Timestamp).Add(info.SignatureWindow())
+	if maxExpire := time.Now().Add(info.SignatureWindow()); expire.After(maxExpire) {
+		expire = maxExpire
+	}
+	hash := signatureHash(args.PublicKey, args.Algorithm, message)
+	usedSignatures.use(hash, expire)
+}
+
+// replayHandler check IPC children signatures in the IPC server replay cache
+func replayHandler(message ipc.Message) ipc.Message {
+	var request replayRequest
+	err := json.Unmarshal([]byte(message.Payload), &request)
+	if err != nil {
+		log.WithError(err).Error("Failed to parse replay request")
+		return ipc.Message{
+			Type:    message.Type,
+			Message: "error",
+		}
+	}
+	data, err := hex.DecodeString(request.Hash)
+	if err != nil || len(data) != sha256.Size {
+		return ipc.Message{
+			Type:    message.Type,
+			Message: "error",
+		}
+	}
+
+	var hash [sha256.Size]byte
+	copy(hash[:], data)
+	result := replayAccepted
+	if !usedSignatures.use(hash, time.Unix(0, request.Expire)) {
+		result = replayRejected
+	}
+	return ipc.Message{
+		Type:    message.Type,
+		Message: result,
+	}
+}
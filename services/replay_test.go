@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"soroban/confidential"
+	"soroban/internal/common"
+	"soroban/internal/memory"
+	"soroban/ipc"
+
+	"golang.org/x/crypto/nacl/sign"
+)
+
+func TestDirectoryEntry_VerifyReplay(t *testing.T) {
+	info := confidential.ConfidentialEntry{
+		Prefix:    "soroban.readonly.*",
+		Algorithm: confidential.AlgorithmNacl,
+		PublicKey: "public",
+		ReadOnly:  true,
+		Tolerance: time.Minute,
+	}
+	ctx := context.Background()
+	now := time.Now().UnixNano()
+	signed := DirectoryEntry{Name: "soroban.readonly.key", Entry: "value", PublicKey: "public", Signature: "signature", Timestamp: now}
+	reencoded := signed
+	reencoded.Signature = "SIGNATURE"
+	other := signed
+	other.Timestamp = now + 1
+
+	tests := []struct {
+		name  string
+		entry DirectoryEntry
+		want  error
+	}{
+		{"first", signed, nil},
+		{"replayed", signed, common.ReplayErr},
+		{"re-encoded signature", reencoded, common.ReplayErr},
+		{"other timestamp", other, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.entry.VerifyReplay(ctx, "directory.Add", info); err != tt.want {
+				t.Errorf("VerifyReplay() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// timestamp out of prefix tolerance
+	stale := signed
+	stale.Timestamp = now - int64(2*time.Minute)
+	if err := stale.VerifySignature("directory.Add", info); err != common.TimestampErr {
+		t.Errorf("VerifySignature() error = %v, want %v", err, common.TimestampErr)
+	}
+}
+
+func Test_rememberSignature(t *testing.T) {
+	publicKey, privateKey, err := sign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := confidential.ConfidentialEntry{
+		Prefix:    "soroban.remember.*",
+		Algorithm: confidential.AlgorithmNacl,
+		PublicKey: hex.EncodeToString(publicKey[:]),
+		ReadOnly:  true,
+		Tolerance: time.Minute,
+	}
+	config := confidential.DefaultSorobanConfig
+	confidential.DefaultSorobanConfig = confidential.SorobanConfig{Confidential: []confidential.ConfidentialEntry{info}}
+	t.Cleanup(func() { confidential.DefaultSorobanConfig = config })
+
+	signed := func(name string, timestamp int64) DirectoryEntry {
+		entry := DirectoryEntry{Name: name, Entry: "value", PublicKey: info.PublicKey, Algorithm: info.Algorithm, Timestamp: timestamp}
+		entry.Signature = hex.EncodeToString(sign.Sign(nil, []byte(entry.SignatureMessage()), privateKey)[:sign.Overhead])
+		return entry
+	}
+	now := time.Now().UnixNano()
+	valid := signed("soroban.remember.key", now)
+	upper := valid
+	upper.Signature = strings.ToUpper(valid.Signature)
+	forged := valid
+	forged.Timestamp = now + 1
+	public := signed("soroban.public.key", now)
+
+	tests := []struct {
+		name    string
+		context string
+		entry   DirectoryEntry
+		want    error
+	}{
+		{"valid", "Directory.Add", valid, common.ReplayErr},
+		{"re-encoded", "Directory.Add", upper, common.ReplayErr},
+		{"forged", "Directory.Add", forged, nil},
+		{"unknown context", "Directory.List", signed("soroban.remember.other", now), nil},
+		{"public key", "Directory.Add", public, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rememberSignature(tt.context, &tt.entry)
+			if err := tt.entry.VerifyReplay(context.Background(), "directory.Add", info); err != tt.want {
+				t.Errorf("VerifyReplay() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// expiry is clamped to the signature window
+	future := signed("soroban.remember.future", now+int64(50*time.Second))
+	rememberSignature("Directory.Add", &future)
+	hash := signatureHash(future.PublicKey, future.Algorithm, future.SignatureMessage())
+	usedSignatures.mtx.Lock()
+	expire, ok := usedSignatures.seen[hash]
+	usedSignatures.mtx.Unlock()
+	if !ok || expire.After(time.Now().Add(info.SignatureWindow())) {
+		t.Errorf("rememberSignature() expire = %v, %v", expire, ok)
+	}
+}
+
+func Test_seenCache_Capacity(t *testing.T) {
+	cache := newSeenCache(2)
+	expire := time.Now().Add(time.Minute)
+	for i, want := range []bool{true, true, false} {
+		hash := signatureHash("public", confidential.AlgorithmNacl, fmt.Sprint(i))
+		if got := cache.use(hash, expire); got != want {
+			t.Errorf("use(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func Test_applyPeerMessage_Malformed(t *testing.T) {
+	info := confidential.ConfidentialEntry{
+		Prefix:    "soroban.malformed.*",
+		Algorithm: confidential.AlgorithmEcdsa,
+		PublicKey: "024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e",
+		ReadOnly:  true,
+	}
+	config := confidential.DefaultSorobanConfig
+	confidential.DefaultSorobanConfig = confidential.SorobanConfig{Confidential: []confidential.ConfidentialEntry{info}}
+	t.Cleanup(func() { confidential.DefaultSorobanConfig = config })
+
+	directory := memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	args := DirectoryEntry{
+		Name:      "soroban.malformed.key",
+		Entry:     "value",
+		PublicKey: info.PublicKey,
+		Algorithm: info.Algorithm,
+		Signature: "zz",
+		Timestamp: time.Now().UnixNano(),
+	}
+	// signature is not remembered, entry is added as other peer messages
+	if err := applyPeerMessage(directory, "Directory.Add", &args); err != nil {
+		t.Errorf("applyPeerMessage() error = %v", err)
+	}
+	if err := applyPeerMessage(directory, "Directory.Unknown", &args); err != errUnknownContext {
+		t.Errorf("applyPeerMessage() error = %v, want %v", err, errUnknownContext)
+	}
+}
+
+func Test_replayHandler(t *testing.T) {
+	hash := signatureHash("public", confidential.AlgorithmNacl, fmt.Sprintf("ipc.%d", time.Now().UnixNano()))
+	data, _ := json.Marshal(replayRequest{
+		Hash:   hex.EncodeToString(hash[:]),
+		Expire: time.Now().Add(time.Minute).UnixNano(),
+	})
+	request := string(data)
+
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"accepted", request, replayAccepted},
+		{"replayed", request, replayRejected},
+		{"invalid", `{"Hash":"00"}`, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := replayHandler(ipc.Message{Type: ipc.MessageTypeReplay, Payload: tt.payload})
+			if resp.Message != tt.want {
+				t.Errorf("replayHandler() = %v, want %v", resp.Message, tt.want)
+			}
+		})
+	}
+}
@@ -10,7 +10,8 @@ import (
 )
 
 var (
-	ErrRegistration = errors.New("service registration failed")
+	ErrRegistration   = errors.New("service registration failed")
+	ErrAdminAlgorithm = errors.New("invalid admin signature algorithm")
 )
 
 type NamedService struct {
@@ -18,10 +19,17 @@ type NamedService struct {
 	Service soroban.Service
 }
 
-func RegisterAll(ctx context.Context, server soroban.Soroban) error {
+func RegisterAll(ctx context.Context, server soroban.Soroban, options soroban.Options, version string) error {
+	if err := checkAdminOptions(options); err != nil {
+		return err
+	}
+
 	services := []NamedService{
 		{"directory", new(Directory)},
+		{"admin", NewAdmin(options)},
 	}
+	// rpc.discover describe all registered services
+	services = append(services, NamedService{"rpc", NewDiscover(version, services)})
 
 	for _, ns := range services {
 		err := server.Register(ctx, ns.Name, ns.Service)
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"soroban/confidential"
+	"soroban/internal/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// StampWindow is the maximum age of proof of work stamps
+	StampWindow = 10 * time.Minute
+
+	// MaxSeenCacheSize is the maximum count of stamps or signatures remembered
+	MaxSeenCacheSize = 1 << 19
+	// fullCacheCleanupInterval is the stale hashes cleanup interval of full caches
+	fullCacheCleanupInterval = time.Second
+)
+
+var usedStamps = newSeenCache(MaxSeenCacheSize)
+
+// seenCache remember stamps & signatures hashes until they are stale
+type seenCache struct {
+	mtx      sync.Mutex
+	seen     map[[sha256.Size]byte]time.Time
+	capacity int
+	cleanup  time.Time
+}
+
+func newSeenCache(capacity int) *seenCache {
+	return &seenCache{
+		seen:     make(map[[sha256.Size]byte]time.Time),
+		capacity: capacity,
+		cleanup:  time.Now(),
+	}
+}
+
+// use return false if hash was already used.
+// Hashes are rejected when cache is full, forgotten hashes could be replayed.
+func (p *seenCache) use(hash [sha256.Size]byte, expire time.Time) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := time.Now()
+	interval := StampWindow
+	if len(p.seen) >= p.capacity {
+		interval = fullCacheCleanupInterval
+	}
+	if now.Sub(p.cleanup) > interval {
+		for stale, expireOn := range p.seen {
+			if now.After(expireOn) {
+				delete(p.seen, stale)
+			}
+		}
+		p.cleanup = now
+	}
+
+	if _, ok := p.seen[hash]; ok {
+		return false
+	}
+	if len(p.seen) >= p.capacity {
+		log.WithField("Size", len(p.seen)).Warning("Seen cache is full")
+		return false
+	}
+	p.seen[hash] = expire
+	return true
+}
+
+// VerifyStamp check entry proof of work, stamps are accepted once within StampWindow.
+func (p *DirectoryEntry) VerifyStamp(info confidential.ConfidentialEntry) error {
+	if info.Difficulty <= 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	timestamp := time.Unix(0, p.Timestamp).UTC()
+	if !timeInRange(now.Add(-StampWindow), now.Add(StampWindow), timestamp) {
+		return common.TimestampErr
+	}
+
+	hash := confidential.StampHash(p.Name, p.Entry, p.Timestamp, p.Nonce)
+	if confidential.LeadingZeros(hash[:]) < info.Difficulty {
+		return common.StampErr
+	}
+	// stamp can't be reused before being stale
+	if !usedStamps.use(hash, timestamp.Add(StampWindow)) {
+		return common.StampReusedErr
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"soroban/confidential"
+	"soroban/internal/common"
+)
+
+func TestDirectoryEntry_VerifyStamp(t *testing.T) {
+	info := confidential.ConfidentialEntry{Prefix: "soroban.pow.*", Difficulty: 8}
+	mint := func(timestamp int64) DirectoryEntry {
+		nonce, err := confidential.MintStamp(context.Background(), "soroban.pow.key", "value", timestamp, info.Difficulty)
+		if err != nil {
+			t.Fatalf("MintStamp() error = %v", err)
+		}
+		return DirectoryEntry{Name: "soroban.pow.key", Entry: "value", Timestamp: timestamp, Nonce: nonce}
+	}
+
+	now := time.Now().UnixNano()
+	valid := mint(now)
+	invalid := valid
+	invalid.Entry = "other"
+
+	tests := []struct {
+		name  string
+		entry DirectoryEntry
+		want  error
+	}{
+		{"valid", valid, nil},
+		{"reused", valid, common.StampReusedErr},
+		{"stale", mint(now - int64(2*StampWindow)), common.TimestampErr},
+		{"invalid", invalid, common.StampErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// invalid entry may match difficulty by chance
+			if tt.name == "invalid" && confidential.VerifyStamp(tt.entry.Name, tt.entry.Entry, tt.entry.Timestamp, tt.entry.Nonce, info.Difficulty) {
+				t.Skip("stamp valid by chance")
+			}
+			if err := tt.entry.VerifyStamp(info); err != tt.want {
+				t.Errorf("VerifyStamp() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
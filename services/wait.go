@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	soroban "soroban"
+	"soroban/confidential"
+	"soroban/internal"
+	"soroban/internal/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultWaitTimeout = 30 * time.Second
+	MaxWaitTimeout     = 60 * time.Second
+	MaxWaitPerConn     = 4
+	// MaxWaitPerAddress is the maximum of held requests per listener & remote address, Tor clients have no address
+	MaxWaitPerAddress = 16
+	// MaxWaiters is the maximum of held requests
+	MaxWaiters = 4096
+
+	// waitPollInterval is used for directories without change notifications
+	waitPollInterval = time.Second
+)
+
+// DirectoryWait for json-rpc request
+// Known entries or Cursor are the entries already seen by the client.
+// Timeout is in seconds, DefaultWaitTimeout if not set.
+type DirectoryWait struct {
+	Name      string
+	Known     []string `json:",omitempty"`
+	Cursor    string   `json:",omitempty"`
+	Timeout   int      `json:",omitempty"`
+	PublicKey string
+	Algorithm string
+	Signature string
+	Timestamp int64
+	// SignatureVersion is confidential.SignatureV1 or confidential.SignatureV2
+	SignatureVersion int `json:",omitempty"`
+}
+
+// Wait hold request until new values are available for key or timeout.
+// Response contains new values only, empty on timeout.
+func (t *Directory) Wait(r *http.Request, args *DirectoryWait, result *DirectoryEntriesResponse) error {
+	ctx := r.Context()
+	directory := internal.DirectoryFromContext(ctx)
+	if directory == nil {
+		log.Error("Directory not found")
+		return common.InternalErr
+	}
+	err := rateLimit(ctx, "directory.Wait", args.Name)
+	if err != nil {
+		return err
+	}
+
+	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
+	// check signature if key is confidential, wait is not allowed for anonymous
+	if info.Confidential {
+		entries := args.entries()
+		err := entries.VerifySignature("directory.Wait", info)
+		if err == nil {
+			err = entries.VerifyReplay(ctx, "directory.Wait", info)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to verifySignature")
+			return err
+		}
+	}
+
+	after, err := decodeCursor(args.Cursor)
+	if err != nil {
+		return err
+	}
+
+	release, err := AcquireWait(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	timeout := DefaultWaitTimeout
+	if args.Timeout > 0 {
+		timeout = time.Duration(args.Timeout) * time.Second
+	}
+	if timeout > MaxWaitTimeout {
+		timeout = MaxWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// watch before listing, changes between list & wait are not lost
+	var events <-chan soroban.Event
+	var poll <-chan time.Time
+	if watcher, ok := directory.(soroban.Watcher); ok {
+		var cancel func()
+		events, cancel = watcher.Watch(args.Name)
+		defer cancel()
+	} else {
+		ticker := time.NewTicker(waitPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	known := make(map[string]struct{}, len(args.Known))
+	for _, value := range args.Known {
+		known[value] = struct{}{}
+	}
+
+	for {
+		response, err := waitEntries(directory, args.Name, after, known)
+		if err != nil {
+			log.WithError(err).Error("Failed to list directory")
+			return err
+		}
+		if len(response.Entries) > 0 {
+			log.Tracef("Wait: %s (%d)", args.Name, len(response.Entries))
+			*result = response
+			return nil
+		}
+
+		select {
+		case <-events:
+		case <-poll:
+		case <-timer.C:
+			*result = response
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *DirectoryWait) entries() *DirectoryEntries {
+	return &DirectoryEntries{
+		Name:      p.Name,
+		PublicKey: p.PublicKey,
+		Algorithm: p.Algorithm,
+		Signature: p.Signature,
+		Timestamp: p.Timestamp,
+
+		SignatureVersion: p.SignatureVersion,
+	}
+}
+
+// waitEntries return entries after cursor and not known, in insertion order.
+func waitEntries(directory soroban.Directory, key string, after uint64, known map[string]struct{}) (DirectoryEntriesResponse, error) {
+	entries, err := directory.Entries(key)
+	if err != nil {
+		return DirectoryEntriesResponse{}, err
+	}
+
+	result := DirectoryEntriesResponse{
+		Name:    key,
+		Entries: make([]string, 0),
+	}
+	if after > 0 {
+		result.Cursor = encodeCursor(after)
+	}
+	for _, entry := range entries {
+		if entry.Sequence <= after {
+			continue
+		}
+		if _, ok := known[entry.Value]; ok {
+			continue
+		}
+		result.Entries = append(result.Entries, entry.Value)
+		result.Cursor = encodeCursor(entry.Sequence)
+	}
+	return result, nil
+}
+
+// heldWaits count held requests of all connections
+var heldWaits = newWaitCounter(MaxWaitPerAddress, MaxWaiters)
+
+// waitCounter count held requests per listener & remote address, and in total
+type waitCounter struct {
+	mtx        sync.Mutex
+	perAddress int
+	max        int
+	addresses  map[string]int
+	total      int
+}
+
+func newWaitCounter(perAddress, max int) *waitCounter {
+	return &waitCounter{
+		perAddress: perAddress,
+		max:        max,
+		addresses:  make(map[string]int),
+	}
+}
+
+// acquire return false if address or total limit is reached, address is not limited if empty
+func (p *waitCounter) acquire(address string) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.total >= p.max {
+		return false
+	}
+	if len(address) > 0 {
+		if p.addresses[address] >= p.perAddress {
+			return false
+		}
+		p.addresses[address]++
+	}
+	p.total++
+	return true
+}
+
+func (p *waitCounter) release(address string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.total--
+	if len(address) > 0 {
+		p.addresses[address]--
+		if p.addresses[address] <= 0 {
+			delete(p.addresses, address)
+		}
+	}
+}
+
+// AcquireWait reserve a held request (Wait or events stream) for the connection in context.
+// Held requests are limited per connection, per listener & remote address and in total.
+// Return TooManyWaitsErr when a limit is reached, release must be called when request ends.
+func AcquireWait(ctx context.Context) (func(), error) {
+	conn := internal.ConnInfoFromContext(ctx)
+	if conn == nil {
+		return func() {}, nil
+	}
+	if conn.Waiters.Add(1) > MaxWaitPerConn {
+		conn.Waiters.Add(-1)
+		return nil, common.TooManyWaitsErr
+	}
+	var address string
+	if len(conn.Address) > 0 {
+		address = conn.Listener + " " + conn.Address
+	}
+	if !heldWaits.acquire(address) {
+		conn.Waiters.Add(-1)
+		return nil, common.TooManyWaitsErr
+	}
+	return func() {
+		heldWaits.release(address)
+		conn.Waiters.Add(-1)
+	}, nil
+}
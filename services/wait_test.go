@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"soroban/internal"
+	"soroban/internal/common"
+	"soroban/internal/memory"
+	"soroban/internal/notify"
+)
+
+func newWaitRequest(t *testing.T, directory *notify.Directory, conn *internal.ConnInfo) *http.Request {
+	ctx := context.WithValue(context.Background(), internal.SorobanDirectoryKey, directory)
+	ctx = context.WithValue(ctx, internal.SorobanConnKey, conn)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestDirectory_Wait(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+	directory.Add("key", "a", directory.TimeToLive("normal"))
+	r := newWaitRequest(t, directory, &internal.ConnInfo{})
+
+	go func() {
+		<-time.After(100 * time.Millisecond)
+		directory.Add("key", "b", directory.TimeToLive("normal"))
+	}()
+
+	var result DirectoryEntriesResponse
+	err := new(Directory).Wait(r, &DirectoryWait{Name: "key", Known: []string{"a"}, Timeout: 5}, &result)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(result.Entries, want) {
+		t.Errorf("Wait() = %v, want %v", result.Entries, want)
+	}
+
+	// nothing new after cursor
+	start := time.Now()
+	err = new(Directory).Wait(r, &DirectoryWait{Name: "key", Cursor: result.Cursor, Timeout: 1}, &result)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if len(result.Entries) != 0 || time.Since(start) < time.Second {
+		t.Errorf("Wait() = %v, want timeout", result.Entries)
+	}
+}
+
+func TestDirectory_WaitPerConnLimit(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+	conn := &internal.ConnInfo{}
+	conn.Waiters.Store(MaxWaitPerConn)
+	r := newWaitRequest(t, directory, conn)
+
+	var result DirectoryEntriesResponse
+	err := new(Directory).Wait(r, &DirectoryWait{Name: "key", Timeout: 1}, &result)
+	if err != common.TooManyWaitsErr {
+		t.Errorf("Wait() error = %v, want %v", err, common.TooManyWaitsErr)
+	}
+	if got := conn.Waiters.Load(); got != MaxWaitPerConn {
+		t.Errorf("Waiters = %d, want %d", got, MaxWaitPerConn)
+	}
+}
+
+func TestDirectory_WaitPerAddressLimit(t *testing.T) {
+	directory := notify.Wrap(memory.New(memory.DefaultCacheCapacity, memory.DefaultCacheTTL))
+
+	// one connection per held request, from the same address
+	var releases []func()
+	for i := 0; i < MaxWaitPerAddress; i++ {
+		ctx := context.WithValue(context.Background(), internal.SorobanConnKey, &internal.ConnInfo{Listener: "IPv4", Address: "10.0.0.1"})
+		release, err := AcquireWait(ctx)
+		if err != nil {
+			t.Fatalf("AcquireWait() error = %v", err)
+		}
+		releases = append(releases, release)
+	}
+
+	var result DirectoryEntriesResponse
+	r := newWaitRequest(t, directory, &internal.ConnInfo{Listener: "IPv4", Address: "10.0.0.1"})
+	if err := new(Directory).Wait(r, &DirectoryWait{Name: "key", Timeout: 1}, &result); err != common.TooManyWaitsErr {
+		t.Errorf("Wait() error = %v, want %v", err, common.TooManyWaitsErr)
+	}
+	other := newWaitRequest(t, directory, &internal.ConnInfo{Listener: "IPv4", Address: "10.0.0.2"})
+	directory.Add("key", "a", directory.TimeToLive("normal"))
+	if err := new(Directory).Wait(other, &DirectoryWait{Name: "key", Timeout: 1}, &result); err != nil {
+		t.Errorf("Wait() other address error = %v", err)
+	}
+
+	for _, release := range releases {
+		release()
+	}
+	if err := new(Directory).Wait(r, &DirectoryWait{Name: "key", Timeout: 1}, &result); err != nil {
+		t.Errorf("Wait() released error = %v", err)
+	}
+}
+
+func Test_waitCounter_Total(t *testing.T) {
+	counter := newWaitCounter(2, 3)
+	for i, want := range []bool{true, true, true, false} {
+		if got := counter.acquire(""); got != want {
+			t.Errorf("acquire(%d) = %v, want %v", i, got, want)
+		}
+	}
+	counter.release("")
+	if !counter.acquire("IPv4 10.0.0.1") {
+		t.Error("acquire() after release = false")
+	}
+}
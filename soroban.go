@@ -34,6 +34,41 @@ type StatusInfo struct {
 	Raw          string    `json:"_raw,omitempty"`
 }
 
+// Entry is a directory value with its metadata
+type Entry struct {
+	Value    string
+	Sequence uint64
+	ExpireOn time.Time
+}
+
+// Event is a directory key change
+// ID is increasing with changes.
+type Event struct {
+	ID    uint64
+	Op    string
+	Key   string
+	Value string
+}
+
+// Watcher is implemented by directories notifying key changes
+type Watcher interface {
+	// Watch return key changes until cancel is called.
+	// Events may be dropped for slow watchers.
+	Watch(key string) (events <-chan Event, cancel func())
+}
+
+// EventLog is implemented by watchers keeping recent key changes
+type EventLog interface {
+	Watcher
+
+	// WatchPrefix return changes of keys starting with prefix until cancel is called.
+	WatchPrefix(prefix string) (events <-chan Event, cancel func())
+
+	// Events return known changes after id for key, or keys starting with key if prefix is set.
+	// complete is false if some changes after id are no longer known.
+	Events(key string, prefix bool, after uint64) (events []Event, complete bool)
+}
+
 // Directory interface
 type Directory interface {
 	// Status returs internal informations
@@ -45,12 +80,19 @@ type Directory interface {
 	// List return all known values for this key.
 	List(key string) ([]string, error)
 
+	// Entries return all known values for this key with metadata, in insertion order.
+	// Sequence is increasing with insertions, refreshed values keep their sequence.
+	Entries(key string) ([]Entry, error)
+
 	// Add value in key.
 	// TimeToLive must be greter or equals to 1 second.
 	// Multiple values can be store with the same key.
-	// TTL is the same for all values.
+	// Each value expires on its own.
 	Add(key, value string, TTL time.Duration) error
 
 	// Remove value from key.
 	Remove(key, value string) error
+
+	// Pop atomically remove and return up to count values from key, in insertion order.
+	Pop(key string, count int) ([]string, error)
 }